@@ -34,7 +34,7 @@ func HCode(data string) (hcode string) {
 	}
 
 	for _, bit := range bits {
-		hcode += string(bit + 48)
+		hcode += string(rune(bit + 48))
 	}
 	return
 
@@ -54,7 +54,7 @@ func isPerfectSquare(n int) bool {
 func errorPosition(p []int) int {
 	str := ""
 	for _, val := range p {
-		str = string(val+48) + str
+		str = string(rune(val+48)) + str
 	}
 
 	number, _ := strconv.ParseInt(str, 2, 0)
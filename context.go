@@ -0,0 +1,112 @@
+package vrf
+
+import (
+	"context"
+)
+
+// ****** Cancellation and Progress ******
+// GenContext is Gen with a context and progress events:
+// * GenParams: the pairing parameters are generated (or taken from
+//   WithParams) and pass the policy
+// * GenGenerator: g and gk are chosen
+// * GenKeyElement: secret key element Index of Total is drawn
+// Parameter generation in pbc cannot be interrupted. When ctx is done first
+// GenContext returns right away and the generation finishes in the
+// background, its result is dropped.
+
+// GenStage is the step a GenEvent reports.
+type GenStage int
+
+const (
+	GenParams GenStage = iota
+	GenGenerator
+	GenKeyElement
+)
+
+func (stage GenStage) String() string {
+	switch stage {
+	case GenParams:
+		return "params"
+	case GenGenerator:
+		return "generator"
+	case GenKeyElement:
+		return "key element"
+	}
+	return "unknown"
+}
+
+// GenEvent is passed to the WithProgress callback.
+type GenEvent struct {
+	Stage GenStage
+	Index int // GenKeyElement only, from 1
+	Total int // GenKeyElement only
+}
+
+// WithProgress calls progress after each step of the generation.
+func WithProgress(progress func(GenEvent)) GenOption {
+	return func(o *genOptions) {
+		o.progress = progress
+	}
+}
+
+func withContext(ctx context.Context) GenOption {
+	return func(o *genOptions) {
+		o.ctx = ctx
+	}
+}
+
+// report stops the generation when the context is done, otherwise it passes
+// event on to the progress callback.
+func (o *genOptions) report(event GenEvent) error {
+	if o.ctx != nil {
+		if err := o.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if o.progress != nil {
+		o.progress(event)
+	}
+	return nil
+}
+
+// run calls generate, giving up when the context is done first.
+func (o *genOptions) run(generate func() (PairingParams, error)) (PairingParams, error) {
+	if o.ctx == nil {
+		return generate()
+	}
+	type result struct {
+		params PairingParams
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		params, err := generate()
+		done <- result{params, err}
+	}()
+	select {
+	case res := <-done:
+		return res.params, res.err
+	case <-o.ctx.Done():
+		return nil, o.ctx.Err()
+	}
+}
+
+// NewParamsContext is NewParams, cancelled with ctx.
+func NewParamsContext(ctx context.Context, lambda uint32, opts ...GenOption) (*Params, error) {
+	return NewParams(lambda, append(opts, withContext(ctx))...)
+}
+
+// GenContext generates params and a key pair like Gen, but returns an error
+// instead of panicking and stops when ctx is done. On error aVRF keeps the
+// params and keys it had.
+func (aVRF *abstractVRF) GenContext(ctx context.Context, lambda uint32, opts ...GenOption) error {
+	if aVRF.typeVRF == "" {
+		panic("...")
+	}
+	opts = append(aVRF.genDefaults(opts), withContext(ctx))
+	params, err := NewParams(lambda, opts...)
+	if err != nil {
+		return err
+	}
+	return aVRF.genKey(params, opts)
+}
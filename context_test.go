@@ -0,0 +1,91 @@
+package vrf
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestGenContextProgress(t *testing.T) {
+	for _, typeVRF := range schemes {
+		var events []GenEvent
+		aVRF := newTestVRF(t, typeVRF, "bn256", WithProgress(func(event GenEvent) {
+			events = append(events, event)
+		}))
+		total := 1
+		if aVRF.secretStart() > 0 {
+			total = aVRF.lCode
+		}
+		want := []GenEvent{{Stage: GenParams}, {Stage: GenGenerator}}
+		for i := 1; i <= total; i++ {
+			want = append(want, GenEvent{Stage: GenKeyElement, Index: i, Total: total})
+		}
+		if !slices.Equal(events, want) {
+			t.Errorf("%s: events %v, want %v", typeVRF, events, want)
+		}
+	}
+}
+
+// failingReader fails once n bytes are read.
+type failingReader struct {
+	n int
+}
+
+var errReader = errors.New("reader failed")
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n < len(p) {
+		return 0, errReader
+	}
+	r.n -= len(p)
+	return len(p), nil
+}
+
+func TestGenKeyFailureKeepsKey(t *testing.T) {
+	x := big.NewInt(5)
+	failures := []struct {
+		name string
+		gen  func(aVRF *abstractVRF) error
+		want error
+	}{
+		{"cancelled", func(aVRF *abstractVRF) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return aVRF.GenContext(ctx, 80)
+		}, context.Canceled},
+		{"cancelled during the key", func(aVRF *abstractVRF) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			return aVRF.GenContext(ctx, 80, WithProgress(func(event GenEvent) {
+				if event.Stage == GenGenerator {
+					cancel()
+				}
+			}))
+		}, context.Canceled},
+		{"reader failed", func(aVRF *abstractVRF) error {
+			// BMR10 and DOD03 fail after a few key elements
+			reader := &failingReader{n: 100 * aVRF.secretStart()}
+			return aVRF.genKey(aVRF.Params(), []GenOption{WithRand(reader)})
+		}, errReader},
+	}
+	for _, typeVRF := range schemes {
+		aVRF := newTestVRF(t, typeVRF, "bn256")
+		value, _ := aVRF.Eval(x)
+		pubKey := aVRF.MarshalPubKey()
+		params := aVRF.Params().Fingerprint()
+		for _, failure := range failures {
+			if err := failure.gen(aVRF); !errors.Is(err, failure.want) {
+				t.Errorf("%s, %s: %v, want %v", typeVRF, failure.name, err, failure.want)
+			}
+			if aVRF.Params().Fingerprint() != params || !slices.Equal(aVRF.MarshalPubKey(), pubKey) {
+				t.Errorf("%s, %s: params or public key changed", typeVRF, failure.name)
+			}
+			got, proof, err := aVRF.TryEval(x)
+			if err != nil || !got.Equals(value) || !aVRF.Verify(x, got, proof) {
+				t.Errorf("%s, %s: old key does not evaluate: %v", typeVRF, failure.name, err)
+			}
+		}
+	}
+}
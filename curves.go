@@ -0,0 +1,123 @@
+package vrf
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ****** Curves and Parameter Files ******
+// Gen either generates a fresh parameter set on a curve family of the backend
+// (WithCurve) or reuses an existing one (WithParams). Existing sets are read
+// from PBC .param files (LoadParamsFile) or from the presets embedded under
+// params/ (PresetParams).
+
+// Curve names a curve family. The pbc families follow the PBC manual.
+type Curve string
+
+const (
+	CurveA     Curve = "a"  // pbc: supersingular, embedding degree 2, symmetric
+	CurveA1    Curve = "a1" // pbc: like A with composite group order
+	CurveD     Curve = "d"  // pbc: MNT, embedding degree 6
+	CurveE     Curve = "e"  // pbc: CM, embedding degree 1
+	CurveF     Curve = "f"  // pbc: Barreto-Naehrig, embedding degree 12
+	CurveG     Curve = "g"  // pbc: Freeman, embedding degree 10
+	CurveBN256 Curve = "bn256"
+)
+
+var (
+	//go:embed params/*.param
+	presets embed.FS
+
+	ErrUnknownCurve  = errors.New("vrf: curve not supported by backend")
+	ErrUnknownPreset = errors.New("vrf: unknown params preset")
+	ErrDiscriminant  = errors.New("vrf: no curve of the requested size for the discriminant")
+)
+
+type genOptions struct {
+	backend      Backend
+	placement    Placement
+	curve        Curve
+	discriminant uint32
+	params       PairingParams
+	policy       *SecurityPolicy
+	rand         io.Reader
+	seed         *[32]byte
+	publicSeed   string
+	keyProof     bool
+	inputBits    int
+	lIn, lCode   int
+	hash         bool
+	ctx          context.Context
+	progress     func(GenEvent)
+}
+
+type GenOption func(*genOptions)
+
+// WithCurve generates the parameters on the given curve family.
+func WithCurve(curve Curve) GenOption {
+	return func(o *genOptions) {
+		o.curve = curve
+	}
+}
+
+// WithDiscriminant sets the CM discriminant searched for Type D and G curves.
+// Type D defaults to 9563, which only yields PBC's d159 sizes (159-bit q);
+// larger sizes need a discriminant of their own, otherwise Gen fails with
+// ErrDiscriminant.
+func WithDiscriminant(d uint32) GenOption {
+	return func(o *genOptions) {
+		o.discriminant = d
+	}
+}
+
+// WithParams skips parameter generation and uses params.
+func WithParams(params PairingParams) GenOption {
+	return func(o *genOptions) {
+		o.params = params
+	}
+}
+
+func newGenOptions(opts []GenOption) *genOptions {
+	o := &genOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// LoadParamsFile reads a PBC .param file, or any file holding the String of
+// PairingParams.
+func LoadParamsFile(name string) (PairingParams, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePairingParams(string(data))
+}
+
+// PresetParams returns an embedded parameter set by name: "a" (PBC a.param),
+// "d159" (PBC d159.param), "f" (PBC f.param) or "bn256". d159 and f are PBC's
+// small sizes with a 158-bit r, below security level 80: they only pass
+// CheckParams under WithPolicy.
+func PresetParams(name string) (PairingParams, error) {
+	data, err := presets.ReadFile(path.Join("params", name+".param"))
+	if err != nil {
+		return nil, ErrUnknownPreset
+	}
+	return ParsePairingParams(string(data))
+}
+
+// Presets lists the names accepted by PresetParams.
+func Presets() []string {
+	var names []string
+	entries, _ := presets.ReadDir("params")
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".param"))
+	}
+	return names
+}
@@ -0,0 +1,80 @@
+package vrf
+
+import (
+	"math/big"
+	"path"
+	"testing"
+)
+
+// presetText reads an embedded preset without a backend, for the checks
+// that only need its numbers.
+type presetText string
+
+func (p presetText) NewPairing() Pairing { return nil }
+func (p presetText) String() string      { return string(p) }
+
+func TestPresetsLoad(t *testing.T) {
+	_, havePBC := LookupBackend("pbc")
+	for _, name := range Presets() {
+		data, _ := presets.ReadFile(path.Join("params", name+".param"))
+		if paramFields(presetText(data))["type"] != "bn256" && !havePBC {
+			t.Logf("%s: pbc backend not built in", name)
+			continue
+		}
+		params, err := PresetParams(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if params.NewPairing().NewG1().SetFromHash([]byte(name)).Is1() {
+			t.Errorf("%s: hashes to the identity", name)
+		}
+	}
+	if _, err := PresetParams("d9563"); err != ErrUnknownPreset {
+		t.Errorf("unknown preset: %v", err)
+	}
+}
+
+func TestPresetInvariants(t *testing.T) {
+	want := map[string]ParamsInfo{
+		"a":    {"a", 160, 512, 2},
+		"d159": {"d", 158, 159, 6},
+		"f":    {"f", 158, 158, 12},
+	}
+	for name, wantInfo := range want {
+		data, err := presets.ReadFile(path.Join("params", name+".param"))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		params := presetText(data)
+		fields := paramFields(params)
+		num := func(key string) *big.Int {
+			n, ok := new(big.Int).SetString(fields[key], 10)
+			if !ok {
+				t.Fatalf("%s: no %s", name, key)
+			}
+			return n
+		}
+		for _, key := range []string{"q", "r"} {
+			if !num(key).ProbablyPrime(20) {
+				t.Errorf("%s: %s is not prime", name, key)
+			}
+		}
+		if name == "d159" {
+			r := num("r")
+			if new(big.Int).Mul(num("h"), r).Cmp(num("n")) != 0 {
+				t.Errorf("%s: n != h r", name)
+			}
+			if new(big.Int).Mul(num("hk"), new(big.Int).Mul(r, r)).Cmp(num("nk")) != 0 {
+				t.Errorf("%s: nk != hk r^2", name)
+			}
+		}
+		info, err := ParamsInfoOf(params)
+		if err != nil || info != wantInfo {
+			t.Errorf("%s: %+v, %v, want %+v", name, info, err, wantInfo)
+		}
+		if err := ImportPolicy.Check(info); name != "a" && err == nil {
+			t.Errorf("%s: toy sizes pass the import policy", name)
+		}
+	}
+}
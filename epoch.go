@@ -0,0 +1,395 @@
+package vrf
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+)
+
+// ****** Forward-Secure Epoch Keys ******
+// An EpochKey is a chain of keys, one per epoch 0, ..., n-1. The key of
+// epoch i is GenKey with WithSeed(s[i]), and the seeds are hash chained:
+//		s[i+1] = SHA256(EpochSeedDST || s[i])
+// Only s[i] of the current epoch is kept. Update moves to the next epoch and
+// wipes s[i] and the key made from it, so a later leak of the EpochKey gives
+// neither the seeds nor the secret keys of past epochs: their outputs can
+// still not be forged or predicted. It does give every future epoch.
+// The public key is the Merkle root of the epoch public keys
+//		leaf[i]: SHA256(0x00 || i || KeyID of epoch i)
+//		node: SHA256(0x01 || left || right)
+// with the leaves padded to a power of two by zero hashes. An EpochProof
+// carries the epoch, its public key and Merkle path next to the proof of the
+// scheme. Setup generates every epoch key once to build the tree, so the
+// number of epochs is capped at MaxEpochs.
+// An EpochKey is safe for concurrent use: Eval never runs with a key that
+// Update is erasing.
+
+const (
+	EpochSeedDST      = "VRF-V01-EPOCH-SEED_SHA-256_" // s[i] -> s[i+1]
+	epochFirstSeedDST = "VRF-EPOCH-V1"                // WithRand/WithSeed -> s[0]
+	epochKeyFormat    = 1
+
+	// MaxEpochs bounds the epochs of an EpochKey: NewEpochKey generates a
+	// key for each of them.
+	MaxEpochs = 1 << 12
+)
+
+var (
+	ErrEpochCount     = errors.New("vrf: invalid number of epochs")
+	ErrEpochPast      = errors.New("vrf: epoch already erased")
+	ErrEpochExhausted = errors.New("vrf: no epochs left")
+	ErrEpochFormat    = errors.New("vrf: invalid epoch key")
+)
+
+// EpochPublicKey is the fixed public key of an EpochKey.
+type EpochPublicKey struct {
+	Scheme string
+	Epochs int
+	Root   [32]byte
+}
+
+// EpochProof is a proof for one epoch.
+type EpochProof struct {
+	Epoch  int
+	PubKey []Element  // public key of the epoch
+	Path   [][32]byte // Merkle siblings, from the leaf up
+	Proof  []Element  // proof of the scheme
+}
+
+type EpochKey struct {
+	typeVRF string
+	opts    []Option
+	params  *Params
+	input   *InputPolicy // of a parsed state, else from opts
+	epochs  int
+	tree    [][][32]byte // tree[0] the leaves, tree[len-1] the root
+
+	mu      sync.Mutex
+	epoch   int
+	seed    [32]byte
+	current *abstractVRF
+}
+
+// NewEpochKey sets up an EpochKey of typeVRF for epochs epochs, starting at
+// epoch 0. opts are those of NewVRF; WithRand or WithSeed give the first
+// seed, crypto/rand otherwise.
+func NewEpochKey(typeVRF string, params *Params, epochs int, opts ...Option) (*EpochKey, error) {
+	if epochs <= 0 || epochs > MaxEpochs {
+		return nil, ErrEpochCount
+	}
+	ek := &EpochKey{typeVRF: typeVRF, opts: opts, params: params, epochs: epochs}
+	r := newGenOptions(opts).reader(epochFirstSeedDST)
+	if r == nil {
+		r = rand.Reader
+	}
+	if _, err := io.ReadFull(r, ek.seed[:]); err != nil {
+		return nil, err
+	}
+
+	leaves := make([][32]byte, epochs)
+	seed := ek.seed
+	for i := 0; i < epochs; i++ {
+		aVRF, err := ek.epochKey(seed)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = epochLeaf(i, aVRF.KeyID())
+		if i == 0 {
+			ek.current = aVRF
+		} else {
+			aVRF.Destroy()
+		}
+		next := nextEpochSeed(seed)
+		clear(seed[:])
+		seed = next
+	}
+	clear(seed[:])
+	ek.tree = merkleTree(leaves)
+	return ek, nil
+}
+
+// epochKey generates the key of the epoch with seed.
+func (ek *EpochKey) epochKey(seed [32]byte) (*abstractVRF, error) {
+	aVRF, err := NewVRF(ek.typeVRF, ek.opts...)
+	if err != nil {
+		return nil, err
+	}
+	if ek.input != nil {
+		aVRF.input = ek.input
+	}
+	if err := aVRF.genKey(ek.params, []GenOption{WithSeed(seed)}); err != nil {
+		return nil, err
+	}
+	return aVRF, nil
+}
+
+func nextEpochSeed(seed [32]byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte(EpochSeedDST))
+	h.Write(seed[:])
+	var next [32]byte
+	h.Sum(next[:0])
+	return next
+}
+
+func epochLeaf(epoch int, keyID string) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	binary.Write(h, binary.BigEndian, uint32(epoch))
+	h.Write([]byte(keyID))
+	var leaf [32]byte
+	h.Sum(leaf[:0])
+	return leaf
+}
+
+func merkleNode(left, right [32]byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left[:])
+	h.Write(right[:])
+	var node [32]byte
+	h.Sum(node[:0])
+	return node
+}
+
+// merkleDepth is the length of the Merkle paths over n leaves.
+func merkleDepth(n int) int {
+	depth := 0
+	for 1<<depth < n {
+		depth++
+	}
+	return depth
+}
+
+// merkleTree builds the levels of the tree over leaves, padded with zero
+// hashes.
+func merkleTree(leaves [][32]byte) [][][32]byte {
+	level := make([][32]byte, 1<<merkleDepth(len(leaves)))
+	copy(level, leaves)
+	tree := [][][32]byte{level}
+	for len(level) > 1 {
+		next := make([][32]byte, len(level)/2)
+		for i := range next {
+			next[i] = merkleNode(level[2*i], level[2*i+1])
+		}
+		tree = append(tree, next)
+		level = next
+	}
+	return tree
+}
+
+// PublicKey returns the public key, the same for every epoch.
+func (ek *EpochKey) PublicKey() *EpochPublicKey {
+	return &EpochPublicKey{
+		Scheme: ek.typeVRF,
+		Epochs: ek.epochs,
+		Root:   ek.tree[len(ek.tree)-1][0],
+	}
+}
+
+// Epoch returns the current epoch.
+func (ek *EpochKey) Epoch() int {
+	ek.mu.Lock()
+	defer ek.mu.Unlock()
+	return ek.epoch
+}
+
+// Update moves to the next epoch, see UpdateTo.
+func (ek *EpochKey) Update() error {
+	ek.mu.Lock()
+	defer ek.mu.Unlock()
+	return ek.updateTo(ek.epoch + 1)
+}
+
+// UpdateTo moves forward to epoch and wipes the seeds and keys of the epochs
+// before it.
+func (ek *EpochKey) UpdateTo(epoch int) error {
+	ek.mu.Lock()
+	defer ek.mu.Unlock()
+	return ek.updateTo(epoch)
+}
+
+func (ek *EpochKey) updateTo(epoch int) error {
+	switch {
+	case epoch < ek.epoch || !ek.current.hasSecKey():
+		return ErrEpochPast
+	case epoch >= ek.epochs:
+		return ErrEpochExhausted
+	case epoch == ek.epoch:
+		return nil
+	}
+	seed := ek.seed
+	for i := ek.epoch; i < epoch; i++ {
+		next := nextEpochSeed(seed)
+		clear(seed[:])
+		seed = next
+	}
+	aVRF, err := ek.epochKey(seed)
+	if err != nil {
+		clear(seed[:])
+		return err
+	}
+	ek.current.Destroy()
+	clear(ek.seed[:])
+	ek.seed, ek.epoch, ek.current = seed, epoch, aVRF
+	clear(seed[:])
+	return nil
+}
+
+// Destroy wipes the seed and key of the current epoch. ek can no longer Eval
+// or Update.
+func (ek *EpochKey) Destroy() {
+	ek.mu.Lock()
+	defer ek.mu.Unlock()
+	clear(ek.seed[:])
+	ek.current.Destroy()
+}
+
+// Eval evaluates x with the key of the current epoch, see TryEval.
+func (ek *EpochKey) Eval(x *big.Int, opts ...EvalOption) (Element, *EpochProof, error) {
+	ek.mu.Lock()
+	defer ek.mu.Unlock()
+	value, proof, err := ek.current.TryEval(x, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var path [][32]byte
+	for level, i := 0, ek.epoch; level < len(ek.tree)-1; level, i = level+1, i/2 {
+		path = append(path, ek.tree[level][i^1])
+	}
+	return value, &EpochProof{
+		Epoch:  ek.epoch,
+		PubKey: ek.current.GetPubKey(),
+		Path:   path,
+		Proof:  proof,
+	}, nil
+}
+
+// VerifyEpoch checks value and proof for x against pk. aVRF is a verifier
+// made with the NewVRF options and params of the EpochKey.
+func (aVRF *abstractVRF) VerifyEpoch(pk *EpochPublicKey, x *big.Int, value Element, proof *EpochProof) bool {
+	if aVRF.typeVRF != pk.Scheme || pk.Epochs > MaxEpochs || proof.Epoch < 0 || proof.Epoch >= pk.Epochs ||
+		len(proof.PubKey) == 0 || len(proof.Path) != merkleDepth(pk.Epochs) {
+		return false
+	}
+	for _, el := range proof.PubKey {
+		if el == nil {
+			return false
+		}
+	}
+
+	verifier := &abstractVRF{
+		typeVRF: aVRF.typeVRF,
+		opts:    aVRF.opts,
+		input:   aVRF.input,
+		backend: aVRF.backend,
+		lIn:     aVRF.lIn,
+	}
+	verifier.UseParams(aVRF.Params())
+	if verifier.SetPubKey(proof.PubKey) != nil {
+		return false
+	}
+
+	node := epochLeaf(proof.Epoch, verifier.KeyID())
+	for level, i := 0, proof.Epoch; level < len(proof.Path); level, i = level+1, i/2 {
+		if i%2 == 0 {
+			node = merkleNode(node, proof.Path[level])
+		} else {
+			node = merkleNode(proof.Path[level], node)
+		}
+	}
+	if node != pk.Root {
+		return false
+	}
+	return verifier.Verify(x, value, proof.Proof)
+}
+
+// ****** Epoch Key State ******
+//		{"version": 1, "scheme": "DY05", "params": <Params.Fingerprint>,
+//		 "l_in", "input", "epoch": i, "seed": <hex s[i]>, "leaves": [<hex>, ...]}
+// The state holds the seed of the current epoch, keep it like a secret key.
+// Options other than the input domain are not saved, they do not change the
+// keys.
+
+type epochKeyState struct {
+	Version int          `json:"version"`
+	Scheme  string       `json:"scheme"`
+	Params  string       `json:"params"`
+	LIn     int          `json:"l_in,omitempty"`
+	Input   *InputPolicy `json:"input,omitempty"`
+	Epoch   int          `json:"epoch"`
+	Seed    string       `json:"seed"`
+	Leaves  []string     `json:"leaves"`
+}
+
+// Marshal encodes the state of ek.
+func (ek *EpochKey) Marshal() ([]byte, error) {
+	ek.mu.Lock()
+	defer ek.mu.Unlock()
+	state := epochKeyState{
+		Version: epochKeyFormat,
+		Scheme:  ek.typeVRF,
+		Params:  ek.params.Fingerprint(),
+		LIn:     ek.current.lIn,
+		Input:   ek.current.input,
+		Epoch:   ek.epoch,
+		Seed:    hex.EncodeToString(ek.seed[:]),
+	}
+	for _, leaf := range ek.tree[0][:ek.epochs] {
+		state.Leaves = append(state.Leaves, hex.EncodeToString(leaf[:]))
+	}
+	return json.Marshal(state)
+}
+
+// ParseEpochKey decodes the output of Marshal for params.
+func ParseEpochKey(data []byte, params *Params) (*EpochKey, error) {
+	var state epochKeyState
+	if err := json.Unmarshal(data, &state); err != nil || state.Version != epochKeyFormat {
+		return nil, ErrEpochFormat
+	}
+	if state.Params != params.Fingerprint() {
+		return nil, ErrKeyringParams
+	}
+	if len(state.Leaves) == 0 || len(state.Leaves) > MaxEpochs || state.Epoch < 0 || state.Epoch >= len(state.Leaves) {
+		return nil, ErrEpochFormat
+	}
+	leaves := make([][32]byte, len(state.Leaves))
+	for i, s := range state.Leaves {
+		if b, err := hex.DecodeString(s); err != nil || copy(leaves[i][:], b) != 32 || len(b) != 32 {
+			return nil, ErrEpochFormat
+		}
+	}
+
+	var opts []Option
+	if state.LIn > 0 {
+		opts = append(opts, WithInputBits(state.LIn))
+	}
+	ek := &EpochKey{
+		typeVRF: state.Scheme,
+		opts:    opts,
+		params:  params,
+		input:   state.Input,
+		epochs:  len(leaves),
+		epoch:   state.Epoch,
+	}
+	seed, err := hex.DecodeString(state.Seed)
+	if err != nil || copy(ek.seed[:], seed) != 32 || len(seed) != 32 {
+		return nil, ErrEpochFormat
+	}
+	clear(seed)
+	ek.tree = merkleTree(leaves)
+	if ek.current, err = ek.epochKey(ek.seed); err != nil {
+		return nil, err
+	}
+	if epochLeaf(ek.epoch, ek.current.KeyID()) != leaves[ek.epoch] {
+		ek.Destroy()
+		return nil, ErrEpochFormat
+	}
+	return ek, nil
+}
@@ -0,0 +1,176 @@
+package vrf
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func testEpochKey(t *testing.T, epochs int) (*EpochKey, *abstractVRF) {
+	t.Helper()
+	backend, _ := LookupBackend("bn256")
+	params, err := NewParams(80, WithBackend(backend))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek, err := NewEpochKey("DY05", params, epochs, WithSeed([32]byte{1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, _ := NewVRF("DY05")
+	verifier.UseParams(params)
+	return ek, verifier
+}
+
+func TestEpochKeyUpdate(t *testing.T) {
+	ek, verifier := testEpochKey(t, 5)
+	pk := ek.PublicKey()
+	x := big.NewInt(3)
+
+	var values []Element
+	var proofs []*EpochProof
+	for epoch := 0; epoch < 5; epoch++ {
+		if err := ek.UpdateTo(epoch); err != nil {
+			t.Fatal(err)
+		}
+		value, proof, err := ek.Eval(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values, proofs = append(values, value), append(proofs, proof)
+	}
+	for epoch := range proofs {
+		if !verifier.VerifyEpoch(pk, x, values[epoch], proofs[epoch]) {
+			t.Errorf("epoch %d does not verify after the update", epoch)
+		}
+		if epoch > 0 && values[epoch].Equals(values[epoch-1]) {
+			t.Errorf("epochs %d and %d share a key", epoch-1, epoch)
+		}
+	}
+	if err := ek.UpdateTo(2); err != ErrEpochPast {
+		t.Errorf("back to epoch 2: %v", err)
+	}
+	if err := ek.Update(); err != ErrEpochExhausted {
+		t.Errorf("past the last epoch: %v", err)
+	}
+	ek.Destroy()
+	if _, _, err := ek.Eval(x); err != ErrDestroyed {
+		t.Errorf("Eval after Destroy: %v", err)
+	}
+	if err := ek.UpdateTo(4); err != ErrEpochPast {
+		t.Errorf("UpdateTo after Destroy: %v", err)
+	}
+}
+
+func TestVerifyEpochTamper(t *testing.T) {
+	ek, verifier := testEpochKey(t, 5)
+	pk := ek.PublicKey()
+	x := big.NewInt(3)
+	ek.UpdateTo(1)
+	value, proof, _ := ek.Eval(x)
+	other, _, _ := ek.Eval(big.NewInt(4))
+
+	tampered := map[string]func(proof EpochProof) EpochProof{
+		"epoch":              func(proof EpochProof) EpochProof { proof.Epoch = 0; return proof },
+		"epoch out of range": func(proof EpochProof) EpochProof { proof.Epoch = 5; return proof },
+		"path": func(proof EpochProof) EpochProof {
+			proof.Path = append([][32]byte{{}}, proof.Path[1:]...)
+			return proof
+		},
+		"short path":   func(proof EpochProof) EpochProof { proof.Path = proof.Path[1:]; return proof },
+		"public key":   func(proof EpochProof) EpochProof { proof.PubKey = []Element{nil}; return proof },
+		"scheme proof": func(proof EpochProof) EpochProof { proof.Proof = []Element{other}; return proof },
+	}
+	for name, tamper := range tampered {
+		bad := tamper(*proof)
+		if verifier.VerifyEpoch(pk, x, value, &bad) {
+			t.Errorf("%s: tampered proof verifies", name)
+		}
+	}
+	if verifier.VerifyEpoch(pk, x, other, proof) {
+		t.Error("another value verifies")
+	}
+	otherKey, _ := testEpochKey(t, 5)
+	if verifier.VerifyEpoch(otherKey.PublicKey(), x, value, proof) {
+		t.Error("proof verifies under another root")
+	}
+}
+
+func TestEpochKeyMarshal(t *testing.T) {
+	ek, verifier := testEpochKey(t, 3)
+	ek.Update()
+	data, err := ek.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseEpochKey(data, ek.params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Epoch() != 1 || parsed.PublicKey().Root != ek.PublicKey().Root {
+		t.Errorf("parsed key at epoch %d", parsed.Epoch())
+	}
+	x := big.NewInt(3)
+	value, proof, _ := parsed.Eval(x)
+	if want, _, _ := ek.Eval(x); !value.Equals(want) || !verifier.VerifyEpoch(ek.PublicKey(), x, value, proof) {
+		t.Error("parsed key evaluates differently")
+	}
+	if _, err := ParseEpochKey(data, newTestVRF(t, "DY05", "bn256").Params()); err != ErrKeyringParams {
+		t.Errorf("other params: %v", err)
+	}
+
+	tampered := map[string]func(state map[string]any){
+		"version": func(state map[string]any) { state["version"] = 2.0 },
+		"epoch":   func(state map[string]any) { state["epoch"] = 3.0 },
+		"seed":    func(state map[string]any) { state["seed"] = "00" },
+		"leaf": func(state map[string]any) {
+			leaves := state["leaves"].([]any)
+			leaves[1] = leaves[0]
+		},
+	}
+	for name, tamper := range tampered {
+		var state map[string]any
+		json.Unmarshal(data, &state)
+		tamper(state)
+		bad, _ := json.Marshal(state)
+		if _, err := ParseEpochKey(bad, ek.params); err != ErrEpochFormat {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestEpochKeyConcurrent(t *testing.T) {
+	ek, verifier := testEpochKey(t, 4)
+	pk := ek.PublicKey()
+	x := big.NewInt(3)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			value, proof, err := ek.Eval(x)
+			if err != nil || !verifier.VerifyEpoch(pk, x, value, proof) {
+				t.Errorf("Eval during Update: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			ek.Update()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEpochCount(t *testing.T) {
+	backend, _ := LookupBackend("bn256")
+	params, err := NewParams(80, WithBackend(backend))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, epochs := range []int{-1, 0, MaxEpochs + 1, 1 << 24} {
+		if _, err := NewEpochKey("DY05", params, epochs); err != ErrEpochCount {
+			t.Errorf("NewEpochKey(%d): %v, want ErrEpochCount", epochs, err)
+		}
+	}
+}
@@ -5,19 +5,32 @@ import (
 	"math/big"
 )
 
+// sampleLevel is the security level of the samples: 80, the only one bn256
+// meets, so they also run on builds without pbc.
+const sampleLevel = 80
+
 func Example() {
-	vrf := NewVRF("BMR10")
-	vrf.Gen(128)
+	vrf, err := NewVRF("BMR10", WithInputBits(32))
+	if err != nil {
+		panic(err)
+	}
+	if err := vrf.Gen(sampleLevel); err != nil {
+		panic(err)
+	}
 	value, proof := vrf.Eval(big.NewInt(100))
 	fmt.Println(vrf.Verify(big.NewInt(100), value, proof))
 
-	vrf = NewVRF("DOD03")
-	vrf.Gen(128)
+	vrf, _ = NewVRF("DOD03")
+	if err := vrf.Gen(sampleLevel); err != nil {
+		panic(err)
+	}
 	value, proof = vrf.Eval(big.NewInt(100))
 	fmt.Println(vrf.Verify(big.NewInt(100), value, proof))
 
-	vrf = NewVRF("DY05")
-	vrf.Gen(128)
+	vrf, _ = NewVRF("DY05")
+	if err := vrf.Gen(sampleLevel); err != nil {
+		panic(err)
+	}
 	value, proof = vrf.Eval(big.NewInt(100))
 	fmt.Println(vrf.Verify(big.NewInt(100), value, proof))
 }
@@ -25,63 +38,76 @@ func Example() {
 func SampleProtocol() {
 	seed := big.NewInt(123)
 	// Alice
-	AliceVRF := NewVRF("DY05")
-	AliceVRF.Gen(128)
+	AliceVRF, _ := NewVRF("DY05")
+	if err := AliceVRF.Gen(sampleLevel); err != nil {
+		panic(err)
+	}
 	params, generator, lIn, lCode := AliceVRF.GetParams()
 	AlicePubKey := AliceVRF.GetPubKey()
 	value, proof := AliceVRF.Eval(seed)
 	// Bob
-	BobVRF := NewVRF("DY05")
-	BobVRF.SetParams(params, generator, lIn, lCode)
+	BobVRF, _ := NewVRF("DY05")
+	if err := BobVRF.SetParams(params, generator, lIn, lCode); err != nil {
+		panic(err)
+	}
 	BobVRF.SetPubKey(AlicePubKey)
 	checkBit := BobVRF.Verify(seed, value, proof)
 	fmt.Println(checkBit)
 }
 
 func SampleGame() {
+	fmt.Println("--------------Step 0: Shared Parameters--------------")
+	params, err := NewParams(sampleLevel)
+	if err != nil {
+		panic(err)
+	}
+	allParams := params.Marshal()
+	fmt.Println("params:", allParams)
+
 	fmt.Println("--------------Step 1: Generation Process--------------")
-	player1 := NewVRF("DY05")
-	player1.Gen(128)
+	// secret keys stay with their owners, only public keys are shown
+	player1, _ := NewVRF("DY05")
+	player1.GenKey(params)
 	p1 := player1.MarshalPubKey()
-	s1 := player1.MarshalSecKey()
-	params1 := player1.MarshalParams()
 	fmt.Println("Player 1:")
 	fmt.Println("Public Key:")
 	fmt.Println("p1:", p1)
-	fmt.Println("Secret Key:")
-	fmt.Println("s1:", s1)
 
-	player2 := NewVRF("DY05")
-	player2.Gen(128)
+	player2, _ := NewVRF("DY05")
+	player2.GenKey(params)
 	p2 := player2.MarshalPubKey()
-	s2 := player2.MarshalSecKey()
-	params2 := player2.MarshalParams()
 	fmt.Println("Player 2:")
 	fmt.Println("Public Key:")
 	fmt.Println("p2:", p2)
-	fmt.Println("Secret Key:")
-	fmt.Println("s2:", s2)
 
-	banker := NewVRF("DY05")
-	banker.Gen(128)
+	banker, _ := NewVRF("DY05")
+	banker.GenKey(params)
 	pb := banker.MarshalPubKey()
-	sb := banker.MarshalSecKey()
-	paramsb := banker.MarshalParams()
 	fmt.Println("Banker:")
 	fmt.Println("Public Key:")
 	fmt.Println("pb:", pb)
-	fmt.Println("Secret Key:")
-	fmt.Println("sb:", sb)
 
 	fmt.Println("--------------Step 2: Publish Key--------------")
-	bankerVRF1 := NewVRF("DY05")
-	bankerVRF1.UnMarshalParams(params1)
-	bankerVRF1.UnMarshalPubKey(p1)
-	bankerVRF2 := NewVRF("DY05")
-	bankerVRF2.UnMarshalParams(params2)
-	bankerVRF2.UnMarshalPubKey(p2)
-	playerVRF := NewVRF("DY05")
-	playerVRF.UnMarshalParams(paramsb)
+	pop1, err := player1.ProvePossession(PossessionContext("SampleGame", "player1"))
+	if err != nil {
+		panic(err)
+	}
+	pop2, err := player2.ProvePossession(PossessionContext("SampleGame", "player2"))
+	if err != nil {
+		panic(err)
+	}
+	registry := NewKeyRegistry("DY05", params, "SampleGame")
+	if err := registry.Register("player1", player1.GetPubKey(), pop1); err != nil {
+		panic(err)
+	}
+	if err := registry.Register("player2", player2.GetPubKey(), pop2); err != nil {
+		panic(err)
+	}
+	fmt.Println("Copied key of player 1:", registry.Register("player3", player1.GetPubKey(), pop1))
+	bankerVRF1, _ := registry.Verifier("player1")
+	bankerVRF2, _ := registry.Verifier("player2")
+	playerVRF, _ := NewVRF("DY05")
+	playerVRF.UnMarshalParams(allParams)
 	playerVRF.UnMarshalPubKey(pb)
 
 	fmt.Println("--------------Step 3: Betting--------------")
@@ -100,30 +126,54 @@ func SampleGame() {
 	fmt.Println("Verification Result:", vers)
 
 	fmt.Println("--------------Step 5: Evaluation--------------")
-	V1, P1 := player1.Eval(seed2.X())
+	// DY05 inputs must lie below the group order
+	round := NewOutput(seed2).Uniform(new(big.Int).Lsh(big.NewInt(1), 128))
+	V1, P1 := player1.Eval(round)
 	fmt.Println("Player 1 evaluation:")
 	fmt.Println("V1:", V1)
 	fmt.Println("P1:", P1)
-	V2, P2 := player2.Eval(seed2.X())
+	V2, P2 := player2.Eval(round)
 	fmt.Println("Player 2 evaluation:")
 	fmt.Println("V2:", V2)
 	fmt.Println("P2:", P2)
 
 	fmt.Println("--------------Step 6: Ranking--------------")
-	fV1 := big.NewInt(1).Mul(v1, V1.X())
+	scale := new(big.Int).Lsh(big.NewInt(1), 128)
+	fV1 := big.NewInt(1).Mul(v1, NewOutput(V1).Uniform(scale))
 	fmt.Println("Player 1 final value:", fV1)
-	fV2 := big.NewInt(1).Mul(v2, V2.X())
+	fV2 := big.NewInt(1).Mul(v2, NewOutput(V2).Uniform(scale))
 	fmt.Println("Player 2 final value:", fV2)
 
 	sg := big.NewInt(0).Sub(fV1, fV2).Sign()
 	if sg == 1 {
-		if bankerVRF1.Verify(seed2.X(), V1, P1) {
+		if bankerVRF1.Verify(round, V1, P1) {
 			fmt.Println("Winner: Player 1")
 		}
 	} else {
-		if bankerVRF2.Verify(seed2.X(), V2, P2) {
+		if bankerVRF2.Verify(round, V2, P2) {
 			fmt.Println("Winner: Player 2")
 		}
 	}
 
 }
+
+// SampleBackends runs every scheme on every compiled-in backend and checks
+// that they agree: honest proofs verify, proofs for another seed do not.
+func SampleBackends() {
+	for _, typeVRF := range []string{"DY05", "BMR10", "DOD03"} {
+		verdicts := map[[2]bool][]string{}
+		for _, name := range Backends() {
+			backend, _ := LookupBackend(name)
+			vrf, _ := NewVRF(typeVRF, WithBackend(backend))
+			if err := vrf.Gen(sampleLevel); err != nil {
+				panic(err)
+			}
+			value, proof := vrf.Eval(big.NewInt(100))
+			valid := vrf.Verify(big.NewInt(100), value, proof)
+			forged := vrf.Verify(big.NewInt(101), value, proof)
+			verdicts[[2]bool{valid, forged}] = append(verdicts[[2]bool{valid, forged}], name)
+			fmt.Println(name, typeVRF, valid, forged)
+		}
+		fmt.Println(typeVRF, "backends agree:", len(verdicts) == 1 && verdicts[[2]bool{true, false}] != nil)
+	}
+}
@@ -0,0 +1,132 @@
+package vrf
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// ****** Group Backend ******
+// The schemes are written once against the interfaces below. A backend
+// provides the bilinear group (G1, G2, GT, Zr and the pairing e) they run in.
+// * pbc: github.com/Nik-U/pbc (cgo, needs libpbc and libgmp)
+// * bn256: golang.org/x/crypto/bn256 (pure Go)
+// The pbc backend is only compiled with cgo and can be left out with the
+// "nopbc" build tag, so the package also builds statically and cross-compiles.
+
+// Element is a mutable element of G1, G2, GT or Zr. The method set follows
+// pbc.Element: the receiver holds the result and is returned for chaining.
+// SetBytes panics on an encoding that does not decode, TrySetBytes returns
+// ErrInvalidElement and leaves the receiver unchanged; use it for data that
+// is not trusted. SetFromHash maps hash to an element whose discrete log is
+// unknown.
+type Element interface {
+	Set(x Element) Element
+	Set0() Element
+	Set1() Element
+	SetInt32(i int32) Element
+	SetBig(i *big.Int) Element
+	SetBytes(buf []byte) Element
+	TrySetBytes(buf []byte) (Element, error)
+	SetString(s string, base int) (Element, bool)
+	SetFromHash(hash []byte) Element
+	Rand() Element
+
+	Add(x, y Element) Element
+	Sub(x, y Element) Element
+	Mul(x, y Element) Element
+	Neg(x Element) Element
+	Invert(x Element) Element
+	PowZn(x, i Element) Element
+	PowBig(x Element, i *big.Int) Element
+	Pair(x, y Element) Element
+
+	ThenAdd(y Element) Element
+	ThenSub(y Element) Element
+	ThenMul(y Element) Element
+	ThenNeg() Element
+	ThenInvert() Element
+	ThenPowZn(i Element) Element
+
+	Equals(x Element) bool
+	Is0() bool
+	Is1() bool
+	Bytes() []byte
+	BigInt() *big.Int
+	X() *big.Int
+	String() string
+	NewFieldElement() Element
+}
+
+// Pairing creates elements of the groups of one parameter set.
+type Pairing interface {
+	NewG1() Element
+	NewG2() Element
+	NewGT() Element
+	NewZr() Element
+	IsSymmetric() bool
+}
+
+// PairingParams is a parameter set of a backend. String is its portable
+// encoding, accepted back by ParsePairingParams.
+type PairingParams interface {
+	NewPairing() Pairing
+	String() string
+}
+
+// Backend generates and parses pairing parameters. GenerateParams gets the
+// sizes picked by SecurityPolicy.Sizes; discriminant is only used by CM
+// curves.
+type Backend interface {
+	Name() string
+	DefaultCurve(level uint32) Curve
+	GenerateParams(curve Curve, rbits, qbits, discriminant uint32) (PairingParams, error)
+	NewParamsFromString(s string) (PairingParams, error)
+}
+
+var (
+	backends = map[string]Backend{}
+
+	ErrUnknownParams  = errors.New("vrf: params not recognised by any backend")
+	ErrInvalidElement = errors.New("vrf: invalid element encoding")
+)
+
+// RegisterBackend makes b available to LookupBackend and ParsePairingParams.
+func RegisterBackend(b Backend) {
+	backends[b.Name()] = b
+}
+
+// LookupBackend returns the registered backend called name.
+func LookupBackend(name string) (Backend, bool) {
+	b, ok := backends[name]
+	return b, ok
+}
+
+// Backends lists the names of the registered backends.
+func Backends() []string {
+	var names []string
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultBackend returns pbc when it is compiled in, bn256 otherwise.
+func DefaultBackend() Backend {
+	if b, ok := backends["pbc"]; ok {
+		return b
+	}
+	return backends["bn256"]
+}
+
+// ParsePairingParams decodes params produced by PairingParams.String of any
+// registered backend.
+func ParsePairingParams(s string) (PairingParams, error) {
+	for _, name := range Backends() {
+		if params, err := backends[name].NewParamsFromString(s); err == nil {
+			return params, nil
+		}
+	}
+	return nil, ErrUnknownParams
+}
@@ -0,0 +1,527 @@
+package vrf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/bn256"
+)
+
+// ****** bn256 Backend ******
+// Pure-Go backend on the 256-bit Barreto-Naehrig curve of
+// golang.org/x/crypto/bn256. The pairing is asymmetric (Type-3): G1 and G2
+// are different groups and e takes one element of each.
+// The curve is fixed, so the sizes are ignored by GenerateParams. It gives
+// about 100 bits of security, enough for level 80 only.
+
+func init() {
+	RegisterBackend(bn256Backend{})
+}
+
+const (
+	bn256ParamsString = "type bn256\n"
+	bn256G1HashDST    = "VRF-V01-BN256-G1_XMD:SHA-256_SVDW_RO_"
+	bn256G2HashDST    = "VRF-V01-BN256-G2_XMD:SHA-256_TAI_RO_"
+)
+
+var (
+	errBn256Params = errors.New("vrf: not bn256 params")
+
+	// bn256P is the base field modulus, unexported by bn256.
+	bn256P, _ = new(big.Int).SetString("65000549695646603732796438742359905742825358107623003571877145026864184071783", 10)
+
+	// bn256TwistB is 3/xi, the b of the twist y^2 = x^3 + b over Fp2, as
+	// (i coefficient, real part), and bn256Cofactor is #E'(Fp2) / r = 2p - r.
+	bn256TwistB = [2]*big.Int{
+		bigFromString("6500054969564660373279643874235990574282535810762300357187714502686418407178"),
+		bigFromString("45500384786952622612957507119651934019977750675336102500314001518804928850249"),
+	}
+	bn256Cofactor = new(big.Int).Sub(new(big.Int).Lsh(bn256P, 1), bn256.Order)
+
+	bn256GTGen = bn256.Pair(
+		new(bn256.G1).ScalarBaseMult(big.NewInt(1)),
+		new(bn256.G2).ScalarBaseMult(big.NewInt(1)),
+	)
+)
+
+type bn256Backend struct{}
+
+func (bn256Backend) Name() string {
+	return "bn256"
+}
+
+func (bn256Backend) DefaultCurve(level uint32) Curve {
+	return CurveBN256
+}
+
+func (bn256Backend) GenerateParams(curve Curve, rbits, qbits, discriminant uint32) (PairingParams, error) {
+	if curve != CurveBN256 {
+		return nil, ErrUnknownCurve
+	}
+	return bn256Params{}, nil
+}
+
+func (bn256Backend) NewParamsFromString(s string) (PairingParams, error) {
+	if strings.TrimSpace(s) != strings.TrimSpace(bn256ParamsString) {
+		return nil, errBn256Params
+	}
+	return bn256Params{}, nil
+}
+
+type bn256Params struct{}
+
+func (bn256Params) NewPairing() Pairing {
+	return bn256Pairing{}
+}
+
+func (bn256Params) String() string {
+	return bn256ParamsString
+}
+
+type bn256Kind int
+
+const (
+	bn256Zr bn256Kind = iota
+	bn256G1
+	bn256G2
+	bn256GT
+)
+
+type bn256Pairing struct{}
+
+func (bn256Pairing) NewG1() Element    { return newBn256Element(bn256G1) }
+func (bn256Pairing) NewG2() Element    { return newBn256Element(bn256G2) }
+func (bn256Pairing) NewGT() Element    { return newBn256Element(bn256GT) }
+func (bn256Pairing) NewZr() Element    { return newBn256Element(bn256Zr) }
+func (bn256Pairing) IsSymmetric() bool { return false }
+
+type bn256Element struct {
+	kind bn256Kind
+	z    *big.Int
+	p    *bn256.G1
+	q    *bn256.G2
+	t    *bn256.GT
+}
+
+func newBn256Element(kind bn256Kind) *bn256Element {
+	el := &bn256Element{kind: kind}
+	el.Set0()
+	return el
+}
+
+func bn(x Element) *bn256Element {
+	return x.(*bn256Element)
+}
+
+// setExp sets el to k for Zr and to generator^k for the groups.
+func (el *bn256Element) setExp(k *big.Int) Element {
+	k = new(big.Int).Mod(k, bn256.Order)
+	switch el.kind {
+	case bn256Zr:
+		el.z = k
+	case bn256G1:
+		el.p = new(bn256.G1).ScalarBaseMult(k)
+	case bn256G2:
+		el.q = new(bn256.G2).ScalarBaseMult(k)
+	case bn256GT:
+		el.t = new(bn256.GT).ScalarMult(bn256GTGen, k)
+	}
+	return el
+}
+
+// setPow sets el to x^k (x*k in Zr).
+func (el *bn256Element) setPow(x *bn256Element, k *big.Int) Element {
+	k = new(big.Int).Mod(k, bn256.Order)
+	el.kind = x.kind
+	switch x.kind {
+	case bn256Zr:
+		el.z = new(big.Int).Mul(x.z, k)
+		el.z.Mod(el.z, bn256.Order)
+	case bn256G1:
+		el.p = new(bn256.G1).ScalarMult(x.p, k)
+	case bn256G2:
+		el.q = new(bn256.G2).ScalarMult(x.q, k)
+	case bn256GT:
+		el.t = new(bn256.GT).ScalarMult(x.t, k)
+	}
+	return el
+}
+
+// setOp sets el to the group operation of x and y.
+func (el *bn256Element) setOp(x, y *bn256Element) Element {
+	el.kind = x.kind
+	switch x.kind {
+	case bn256Zr:
+		el.z = new(big.Int).Add(x.z, y.z)
+		el.z.Mod(el.z, bn256.Order)
+	case bn256G1:
+		el.p = new(bn256.G1).Add(x.p, y.p)
+	case bn256G2:
+		el.q = new(bn256.G2).Add(x.q, y.q)
+	case bn256GT:
+		el.t = new(bn256.GT).Add(x.t, y.t)
+	}
+	return el
+}
+
+// Set copies x: the bn256 values are not shared, since Marshal normalizes
+// points in place.
+func (el *bn256Element) Set(x Element) Element {
+	return el.setPow(bn(x), big.NewInt(1))
+}
+
+func (el *bn256Element) Set0() Element {
+	return el.setExp(big.NewInt(0))
+}
+
+func (el *bn256Element) Set1() Element {
+	if el.kind == bn256Zr {
+		return el.setExp(big.NewInt(1))
+	}
+	return el.Set0()
+}
+
+func (el *bn256Element) SetInt32(i int32) Element {
+	return el.SetBig(big.NewInt(int64(i)))
+}
+
+func (el *bn256Element) SetBig(i *big.Int) Element {
+	if el.kind != bn256Zr {
+		panic("vrf: bn256 group elements cannot be set from integers")
+	}
+	return el.setExp(i)
+}
+
+func (el *bn256Element) SetBytes(buf []byte) Element {
+	if !el.setBytes(buf) {
+		panic("vrf: invalid bn256 element encoding")
+	}
+	return el
+}
+
+// TrySetBytes only takes the 32-byte encoding of a reduced exponent for Zr,
+// and points in the group of order r for G1 and G2.
+func (el *bn256Element) TrySetBytes(buf []byte) (Element, error) {
+	if el.kind == bn256Zr && (len(buf) != 32 || new(big.Int).SetBytes(buf).Cmp(bn256.Order) >= 0) {
+		return nil, ErrInvalidElement
+	}
+	if !el.setBytes(buf) {
+		return nil, ErrInvalidElement
+	}
+	return el, nil
+}
+
+func (el *bn256Element) setBytes(buf []byte) bool {
+	switch el.kind {
+	case bn256Zr:
+		el.setExp(new(big.Int).SetBytes(buf))
+	case bn256G1:
+		p, ok := new(bn256.G1).Unmarshal(buf)
+		if !ok {
+			return false
+		}
+		el.p = p
+	case bn256G2:
+		// The twist has a cofactor, so check the point is in the r-torsion.
+		q, ok := new(bn256.G2).Unmarshal(buf)
+		if !ok || !isZero(new(bn256.G2).ScalarMult(q, bn256.Order).Marshal()) {
+			return false
+		}
+		el.q = q
+	case bn256GT:
+		t, ok := new(bn256.GT).Unmarshal(buf)
+		if !ok {
+			return false
+		}
+		el.t = t
+	}
+	return true
+}
+
+// SetString accepts a number in the given base for Zr and the hex encoding
+// of Bytes for the groups, i.e. whatever String returned.
+func (el *bn256Element) SetString(s string, base int) (Element, bool) {
+	if el.kind == bn256Zr {
+		i, ok := new(big.Int).SetString(s, base)
+		if !ok {
+			return nil, false
+		}
+		return el.setExp(i), true
+	}
+	buf, err := hex.DecodeString(s)
+	if err != nil || !el.setBytes(buf) {
+		return nil, false
+	}
+	return el, true
+}
+
+// SetFromHash reduces hash mod r for Zr. For the groups the discrete log of
+// the result is unknown, like with pbc:
+// * G1: hash to curve (SvdW) of hash under bn256G1HashDST
+// * G2: try and increment on the twist, see hashToTwist
+// * GT: the pairing of the G1 and G2 points of hash
+func (el *bn256Element) SetFromHash(hash []byte) Element {
+	switch el.kind {
+	case bn256G1:
+		p, err := hashToG1(bn256Params{}, bn256Pairing{}, []byte(bn256G1HashDST), hash)
+		if err != nil {
+			panic(err)
+		}
+		return el.Set(p)
+	case bn256G2:
+		el.q = hashToTwist(hash)
+	case bn256GT:
+		p := newBn256Element(bn256G1).SetFromHash(hash)
+		q := newBn256Element(bn256G2).SetFromHash(hash)
+		return el.Pair(p, q)
+	default:
+		el.setExp(new(big.Int).SetBytes(hash))
+	}
+	return el
+}
+
+func (el *bn256Element) Rand() Element {
+	k, err := rand.Int(rand.Reader, bn256.Order)
+	if err != nil {
+		panic(err)
+	}
+	return el.setExp(k)
+}
+
+func (el *bn256Element) Add(x, y Element) Element {
+	return el.setOp(bn(x), bn(y))
+}
+
+func (el *bn256Element) Sub(x, y Element) Element {
+	return el.setOp(bn(x), bn(newBn256Element(bn(y).kind).Neg(y)))
+}
+
+func (el *bn256Element) Mul(x, y Element) Element {
+	if bn(x).kind == bn256Zr {
+		return el.setPow(bn(x), bn(y).z)
+	}
+	return el.setOp(bn(x), bn(y))
+}
+
+func (el *bn256Element) Neg(x Element) Element {
+	if bn(x).kind == bn256Zr {
+		return el.setPow(bn(x), big.NewInt(-1))
+	}
+	return el.Invert(x)
+}
+
+func (el *bn256Element) Invert(x Element) Element {
+	if bn(x).kind == bn256Zr {
+		el.kind = bn256Zr
+		z := new(big.Int).ModInverse(bn(x).z, bn256.Order)
+		if z == nil {
+			panic("vrf: bn256 inverse of zero")
+		}
+		el.z = z
+		return el
+	}
+	return el.setPow(bn(x), new(big.Int).Sub(bn256.Order, big.NewInt(1)))
+}
+
+func (el *bn256Element) PowZn(x, i Element) Element {
+	return el.PowBig(x, bn(i).z)
+}
+
+func (el *bn256Element) PowBig(x Element, i *big.Int) Element {
+	if bn(x).kind == bn256Zr {
+		el.kind = bn256Zr
+		el.z = new(big.Int).Exp(bn(x).z, i, bn256.Order)
+		return el
+	}
+	return el.setPow(bn(x), i)
+}
+
+// Pair takes x in G1 and y in G2.
+func (el *bn256Element) Pair(x, y Element) Element {
+	el.kind = bn256GT
+	el.t = bn256.Pair(bn(x).p, bn(y).q)
+	return el
+}
+
+func (el *bn256Element) ThenAdd(y Element) Element   { return el.Add(el, y) }
+func (el *bn256Element) ThenSub(y Element) Element   { return el.Sub(el, y) }
+func (el *bn256Element) ThenMul(y Element) Element   { return el.Mul(el, y) }
+func (el *bn256Element) ThenNeg() Element            { return el.Neg(el) }
+func (el *bn256Element) ThenInvert() Element         { return el.Invert(el) }
+func (el *bn256Element) ThenPowZn(i Element) Element { return el.PowZn(el, i) }
+
+func (el *bn256Element) Equals(x Element) bool {
+	other, ok := x.(*bn256Element)
+	return ok && el.kind == other.kind && bytes.Equal(el.Bytes(), other.Bytes())
+}
+
+// Zeroize overwrites the exponent in place before resetting el.
+func (el *bn256Element) Zeroize() {
+	if el.z != nil {
+		clear(el.z.Bits())
+	}
+	el.Set0()
+}
+
+func (el *bn256Element) Is0() bool {
+	if el.kind == bn256Zr {
+		return el.z.Sign() == 0
+	}
+	return el.Equals(newBn256Element(el.kind))
+}
+
+func (el *bn256Element) Is1() bool {
+	if el.kind == bn256Zr {
+		return el.z.Cmp(big.NewInt(1)) == 0
+	}
+	return el.Is0()
+}
+
+func (el *bn256Element) Bytes() []byte {
+	switch el.kind {
+	case bn256G1:
+		return el.p.Marshal()
+	case bn256G2:
+		return el.q.Marshal()
+	case bn256GT:
+		return el.t.Marshal()
+	}
+	return el.z.FillBytes(make([]byte, 32))
+}
+
+func (el *bn256Element) BigInt() *big.Int {
+	if el.kind == bn256Zr {
+		return new(big.Int).Set(el.z)
+	}
+	return new(big.Int).SetBytes(el.Bytes())
+}
+
+// X returns the (first coefficient of the) x-coordinate for the groups and
+// the first coefficient for GT.
+func (el *bn256Element) X() *big.Int {
+	if el.kind == bn256Zr {
+		return new(big.Int).Set(el.z)
+	}
+	return new(big.Int).SetBytes(el.Bytes()[:32])
+}
+
+func (el *bn256Element) String() string {
+	if el.kind == bn256Zr {
+		return el.z.String()
+	}
+	return hex.EncodeToString(el.Bytes())
+}
+
+func (el *bn256Element) NewFieldElement() Element {
+	return newBn256Element(el.kind)
+}
+
+func isZero(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func bigFromString(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// ****** Hash to G2 ******
+// G2 is the r-torsion of the twist E': y^2 = x^3 + b over Fp2 = Fp[i]/(i^2+1).
+// hashToTwist hashes to it by try and increment:
+//		x: hash_to_field(hash || ctr) with two coefficients, ctr = 0, 1, ...
+//		y: the square root of x^3 + b, if there is one
+// and clears the cofactor of E'.
+
+// hashToTwist maps hash to G2. It is not constant time, the input is public.
+func hashToTwist(hash []byte) *bn256.G2 {
+	for ctr := 0; ; ctr++ {
+		u, err := hashToField(append(append([]byte{}, hash...), byte(ctr>>8), byte(ctr)), []byte(bn256G2HashDST), 2, bn256P)
+		if err != nil {
+			panic(err)
+		}
+		x := fp2{u[1], u[0]}
+		y, ok := x.square().mul(x).add(fp2{bn256TwistB[0], bn256TwistB[1]}).sqrt()
+		if !ok {
+			continue
+		}
+		buf := make([]byte, 128)
+		x[0].FillBytes(buf[0:32])
+		x[1].FillBytes(buf[32:64])
+		y[0].FillBytes(buf[64:96])
+		y[1].FillBytes(buf[96:128])
+		q, ok := new(bn256.G2).Unmarshal(buf)
+		if !ok {
+			panic("vrf: hash to G2 left the twist")
+		}
+		q.ScalarMult(q, bn256Cofactor)
+		if !isZero(q.Marshal()) {
+			return q
+		}
+	}
+}
+
+// fp2 is a i + b in Fp2 as {a, b}, the layout of bn256.
+type fp2 [2]*big.Int
+
+func (x fp2) add(y fp2) fp2 {
+	return fp2{
+		new(big.Int).Mod(new(big.Int).Add(x[0], y[0]), bn256P),
+		new(big.Int).Mod(new(big.Int).Add(x[1], y[1]), bn256P),
+	}
+}
+
+func (x fp2) mul(y fp2) fp2 {
+	// (a i + b)(c i + d) = (a d + b c) i + (b d - a c)
+	a := new(big.Int).Add(new(big.Int).Mul(x[0], y[1]), new(big.Int).Mul(x[1], y[0]))
+	b := new(big.Int).Sub(new(big.Int).Mul(x[1], y[1]), new(big.Int).Mul(x[0], y[0]))
+	return fp2{a.Mod(a, bn256P), b.Mod(b, bn256P)}
+}
+
+func (x fp2) square() fp2 {
+	return x.mul(x)
+}
+
+// sqrt returns a square root of x, with p = 3 mod 4: from the root l of the
+// norm b^2 + a^2, the real part of the root is sqrt((b + l) / 2) or
+// sqrt((b - l) / 2).
+func (x fp2) sqrt() (fp2, bool) {
+	half := new(big.Int).ModInverse(big.NewInt(2), bn256P)
+	if x[0].Sign() == 0 {
+		if r := new(big.Int).ModSqrt(x[1], bn256P); r != nil {
+			return fp2{new(big.Int), r}, true
+		}
+		// -1 is not a square, so -b is
+		r := new(big.Int).ModSqrt(new(big.Int).Sub(bn256P, x[1]), bn256P)
+		return fp2{r, new(big.Int)}, true
+	}
+	norm := new(big.Int).Add(new(big.Int).Mul(x[0], x[0]), new(big.Int).Mul(x[1], x[1]))
+	l := new(big.Int).ModSqrt(norm.Mod(norm, bn256P), bn256P)
+	if l == nil {
+		return fp2{}, false
+	}
+	for _, sign := range []int{1, -1} {
+		d := new(big.Int).Add(x[1], new(big.Int).Mul(big.NewInt(int64(sign)), l))
+		d.Mul(d, half).Mod(d, bn256P)
+		b := new(big.Int).ModSqrt(d, bn256P)
+		if b == nil || b.Sign() == 0 {
+			continue
+		}
+		// a = a_x / (2 b)
+		a := new(big.Int).ModInverse(new(big.Int).Lsh(b, 1), bn256P)
+		a.Mul(a, x[0]).Mod(a, bn256P)
+		root := fp2{a, b}
+		if sq := root.square(); sq[0].Cmp(x[0]) == 0 && sq[1].Cmp(x[1]) == 0 {
+			return root, true
+		}
+	}
+	return fp2{}, false
+}
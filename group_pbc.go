@@ -0,0 +1,222 @@
+//go:build cgo && !nopbc
+
+package vrf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Nik-U/pbc"
+)
+
+// ****** pbc Backend ******
+// Thin adapter from the Element/Pairing interfaces to github.com/Nik-U/pbc.
+// GenerateParams covers the pbc curve families; Type A below security level
+// 112 (symmetric, the historical default), Type F from there on.
+// * A, E: rbits, qbits as given
+// * A1: order n = p1 p2 with two rbits/2-bit primes
+// * D, G: CM search for discriminant d
+// * F: rbits as given, q has the same size
+
+func init() {
+	RegisterBackend(pbcBackend{})
+}
+
+type pbcBackend struct{}
+
+func (pbcBackend) Name() string {
+	return "pbc"
+}
+
+func (pbcBackend) DefaultCurve(level uint32) Curve {
+	if level < 112 {
+		return CurveA
+	}
+	return CurveF
+}
+
+func (pbcBackend) GenerateParams(curve Curve, rbits, qbits, discriminant uint32) (PairingParams, error) {
+	var params *pbc.Params
+	var err error
+	switch curve {
+	case CurveA:
+		params = pbc.GenerateA(rbits, qbits)
+	case CurveA1:
+		p1, err1 := rand.Prime(rand.Reader, int(rbits/2))
+		p2, err2 := rand.Prime(rand.Reader, int(rbits/2))
+		if err1 != nil || err2 != nil {
+			return nil, errors.Join(err1, err2)
+		}
+		params = pbc.GenerateA1(new(big.Int).Mul(p1, p2))
+	case CurveD:
+		if discriminant == 0 {
+			discriminant = 9563
+		}
+		params, err = pbc.GenerateD(discriminant, rbits, qbits, 2*qbits)
+		if err != nil {
+			return nil, fmt.Errorf("%w: D%d (default 9563, see WithDiscriminant): %v", ErrDiscriminant, discriminant, err)
+		}
+	case CurveE:
+		params = pbc.GenerateE(rbits, qbits)
+	case CurveF:
+		params = pbc.GenerateF(rbits)
+	case CurveG:
+		if discriminant == 0 {
+			return nil, errors.New("vrf: Type G curves need WithDiscriminant")
+		}
+		params, err = pbc.GenerateG(discriminant, rbits, qbits, 2*qbits)
+		if err != nil {
+			return nil, fmt.Errorf("%w: G%d: %v", ErrDiscriminant, discriminant, err)
+		}
+	default:
+		return nil, ErrUnknownCurve
+	}
+	generated := &pbcParams{params}
+	if curve == CurveD || curve == CurveG {
+		// the CM search returns whatever curve the discriminant admits
+		info, err := ParamsInfoOf(generated)
+		if err != nil {
+			return nil, err
+		}
+		if info.RBits < int(rbits) || info.QBits < int(qbits) {
+			return nil, fmt.Errorf("%w: %s%d gives r %d, q %d bits, want %d, %d (see WithDiscriminant)",
+				ErrDiscriminant, strings.ToUpper(string(curve)), discriminant, info.RBits, info.QBits, rbits, qbits)
+		}
+	}
+	return generated, nil
+}
+
+func (pbcBackend) NewParamsFromString(s string) (PairingParams, error) {
+	params, err := pbc.NewParamsFromString(s)
+	if err != nil {
+		return nil, err
+	}
+	return &pbcParams{params}, nil
+}
+
+type pbcParams struct {
+	params *pbc.Params
+}
+
+func (p *pbcParams) NewPairing() Pairing {
+	fields := paramFields(p)
+	order, ok := new(big.Int).SetString(fields["r"], 10)
+	if fields["type"] == "a1" {
+		order, ok = new(big.Int).SetString(fields["n"], 10)
+	}
+	if !ok {
+		order = nil
+	}
+	return &pbcPairing{p.params.NewPairing(), order}
+}
+
+func (p *pbcParams) String() string {
+	return p.params.String()
+}
+
+type pbcPairing struct {
+	pairing *pbc.Pairing
+	order   *big.Int // r, the group order
+}
+
+func (p *pbcPairing) NewG1() Element    { return &pbcElement{p.pairing.NewG1(), p.order} }
+func (p *pbcPairing) NewG2() Element    { return &pbcElement{p.pairing.NewG2(), p.order} }
+func (p *pbcPairing) NewGT() Element    { return &pbcElement{p.pairing.NewGT(), p.order} }
+func (p *pbcPairing) NewZr() Element    { return &pbcElement{p.pairing.NewZr(), nil} }
+func (p *pbcPairing) IsSymmetric() bool { return p.pairing.IsSymmetric() }
+
+type pbcElement struct {
+	e     *pbc.Element
+	order *big.Int // of G1, G2 and GT, nil for Zr
+}
+
+// unwrap panics when x comes from another backend, like pbc does for
+// elements of different pairings.
+func unwrap(x Element) *pbc.Element {
+	return x.(*pbcElement).e
+}
+
+func (el *pbcElement) then(e *pbc.Element) Element {
+	el.e = e
+	return el
+}
+
+func (el *pbcElement) Set(x Element) Element           { return el.then(el.e.Set(unwrap(x))) }
+func (el *pbcElement) Set0() Element                   { return el.then(el.e.Set0()) }
+func (el *pbcElement) Set1() Element                   { return el.then(el.e.Set1()) }
+func (el *pbcElement) SetInt32(i int32) Element        { return el.then(el.e.SetInt32(i)) }
+func (el *pbcElement) SetBig(i *big.Int) Element       { return el.then(el.e.SetBig(i)) }
+func (el *pbcElement) SetBytes(buf []byte) Element     { return el.then(el.e.SetBytes(buf)) }
+func (el *pbcElement) SetFromHash(hash []byte) Element { return el.then(el.e.SetFromHash(hash)) }
+func (el *pbcElement) Rand() Element                   { return el.then(el.e.Rand()) }
+
+// TrySetBytes requires buf to be the encoding pbc gives back: pbc reads a
+// fixed length from buf, even past its end, and reduces the coordinates.
+// pbc takes any point of the curve, so group elements must also have order
+// r: a point of the cofactor part pairs to 1 and would give a second valid
+// output.
+func (el *pbcElement) TrySetBytes(buf []byte) (Element, error) {
+	if len(buf) != len(el.e.Bytes()) {
+		return nil, ErrInvalidElement
+	}
+	e := el.e.NewFieldElement().SetBytes(buf)
+	if !bytes.Equal(e.Bytes(), buf) {
+		return nil, ErrInvalidElement
+	}
+	if el.order != nil && !e.NewFieldElement().PowBig(e, el.order).Is1() {
+		return nil, ErrInvalidElement
+	}
+	return el.then(el.e.Set(e)), nil
+}
+
+func (el *pbcElement) SetString(s string, base int) (Element, bool) {
+	e, ok := el.e.SetString(s, base)
+	if !ok {
+		return nil, false
+	}
+	return el.then(e), true
+}
+
+func (el *pbcElement) Add(x, y Element) Element { return el.then(el.e.Add(unwrap(x), unwrap(y))) }
+func (el *pbcElement) Sub(x, y Element) Element { return el.then(el.e.Sub(unwrap(x), unwrap(y))) }
+func (el *pbcElement) Mul(x, y Element) Element { return el.then(el.e.Mul(unwrap(x), unwrap(y))) }
+func (el *pbcElement) Neg(x Element) Element    { return el.then(el.e.Neg(unwrap(x))) }
+func (el *pbcElement) Invert(x Element) Element { return el.then(el.e.Invert(unwrap(x))) }
+func (el *pbcElement) PowZn(x, i Element) Element {
+	return el.then(el.e.PowZn(unwrap(x), unwrap(i)))
+}
+func (el *pbcElement) PowBig(x Element, i *big.Int) Element {
+	return el.then(el.e.PowBig(unwrap(x), i))
+}
+func (el *pbcElement) Pair(x, y Element) Element { return el.then(el.e.Pair(unwrap(x), unwrap(y))) }
+
+func (el *pbcElement) ThenAdd(y Element) Element   { return el.then(el.e.ThenAdd(unwrap(y))) }
+func (el *pbcElement) ThenSub(y Element) Element   { return el.then(el.e.ThenSub(unwrap(y))) }
+func (el *pbcElement) ThenMul(y Element) Element   { return el.then(el.e.ThenMul(unwrap(y))) }
+func (el *pbcElement) ThenNeg() Element            { return el.then(el.e.ThenNeg()) }
+func (el *pbcElement) ThenInvert() Element         { return el.then(el.e.ThenInvert()) }
+func (el *pbcElement) ThenPowZn(i Element) Element { return el.then(el.e.ThenPowZn(unwrap(i))) }
+
+func (el *pbcElement) Equals(x Element) bool {
+	other, ok := x.(*pbcElement)
+	return ok && el.e.Equals(other.e)
+}
+
+// Zeroize overwrites el with a random value before resetting it; GMP reuses
+// the limbs of the old value when it can.
+func (el *pbcElement) Zeroize() {
+	el.e.Rand()
+	el.e.Set0()
+}
+
+func (el *pbcElement) Is0() bool                { return el.e.Is0() }
+func (el *pbcElement) Is1() bool                { return el.e.Is1() }
+func (el *pbcElement) Bytes() []byte            { return el.e.Bytes() }
+func (el *pbcElement) BigInt() *big.Int         { return el.e.BigInt() }
+func (el *pbcElement) X() *big.Int              { return el.e.X() }
+func (el *pbcElement) String() string           { return el.e.String() }
+func (el *pbcElement) NewFieldElement() Element { return &pbcElement{el.e.NewFieldElement(), el.order} }
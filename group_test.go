@@ -0,0 +1,175 @@
+package vrf
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+var schemes = []string{"DY05", "BMR10", "DOD03"}
+
+// newTestVRF generates a level 80 key of typeVRF on the backend called name.
+// opts are passed to NewVRF.
+func newTestVRF(t testing.TB, typeVRF, name string, opts ...Option) *abstractVRF {
+	t.Helper()
+	backend, ok := LookupBackend(name)
+	if !ok {
+		t.Fatalf("backend %s not registered", name)
+	}
+	aVRF, err := NewVRF(typeVRF, append([]Option{WithBackend(backend)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := aVRF.GenContext(context.Background(), 80); err != nil {
+		t.Fatal(err)
+	}
+	return aVRF
+}
+
+// backendVerdicts evaluates x and checks the output, the output for another
+// input, another value and a proof that does not decode.
+func backendVerdicts(t *testing.T, aVRF *abstractVRF) [4]bool {
+	x := big.NewInt(100)
+	value, proof := aVRF.Eval(x)
+	other, _ := aVRF.Eval(big.NewInt(102))
+	broken := append([]Element{}, proof...)
+	broken[len(broken)-1] = aVRF.pairing.NewZr()
+	return [4]bool{
+		aVRF.Verify(x, value, proof),
+		aVRF.Verify(big.NewInt(101), value, proof),
+		aVRF.Verify(x, other, proof),
+		aVRF.Verify(x, value, broken),
+	}
+}
+
+func TestBackendsAgree(t *testing.T) {
+	want := [4]bool{true, false, false, false}
+	for _, typeVRF := range schemes {
+		for _, name := range Backends() {
+			got := backendVerdicts(t, newTestVRF(t, typeVRF, name))
+			if got != want {
+				t.Errorf("%s on %s: valid, other input, other value, undecodable = %v, want %v", typeVRF, name, got, want)
+			}
+		}
+	}
+}
+
+func TestBackendPlacement(t *testing.T) {
+	for _, name := range Backends() {
+		for _, typeVRF := range schemes {
+			aVRF := newTestVRF(t, typeVRF, name, WithPlacement(PubKeyInG1))
+			if got := backendVerdicts(t, aVRF); got != [4]bool{true, false, false, false} {
+				t.Errorf("%s on %s with keys in G1: %v", typeVRF, name, got)
+			}
+		}
+	}
+}
+
+func TestTrySetBytes(t *testing.T) {
+	for _, name := range Backends() {
+		aVRF := newTestVRF(t, "DY05", name)
+		pairing := aVRF.pairing
+		for _, newElement := range []func() Element{pairing.NewG1, pairing.NewG2, pairing.NewGT, pairing.NewZr} {
+			el := newElement().SetFromHash([]byte("element"))
+			decoded, err := newElement().TrySetBytes(el.Bytes())
+			if err != nil || !decoded.Equals(el) {
+				t.Errorf("%s: %v does not decode: %v", name, el, err)
+			}
+			if _, err := newElement().TrySetBytes(el.Bytes()[1:]); err == nil {
+				t.Errorf("%s: truncated %v decodes", name, el)
+			}
+		}
+		if newElement, buf, ok := cofactorPoint(aVRF); ok {
+			if _, err := newElement().TrySetBytes(buf); err != ErrInvalidElement {
+				t.Errorf("%s: point outside the group of order r decodes: %v", name, err)
+			}
+		}
+	}
+}
+
+// cofactorPoint encodes a point of the curve of G1, or of the twist G2 on
+// bn256, that is not in the group of order r. ok is false when that curve
+// has no such points.
+func cofactorPoint(aVRF *abstractVRF) (newElement func() Element, buf []byte, ok bool) {
+	if paramFields(aVRF.params)["type"] == "bn256" {
+		// the twist has cofactor 2p - r, so almost no point has order r
+		for c := int64(1); ; c++ {
+			x := fp2{big.NewInt(1), big.NewInt(c)}
+			y, ok := x.square().mul(x).add(fp2{bn256TwistB[0], bn256TwistB[1]}).sqrt()
+			if !ok {
+				continue
+			}
+			buf = make([]byte, 128)
+			for i, n := range []*big.Int{x[0], x[1], y[0], y[1]} {
+				n.FillBytes(buf[32*i : 32*(i+1)])
+			}
+			return aVRF.pairing.NewG2, buf, true
+		}
+	}
+	curve, err := curveOf(aVRF.params)
+	if err != nil || curve.h.Cmp(big.NewInt(1)) == 0 {
+		return nil, nil, false
+	}
+	size := len(aVRF.pairing.NewG1().Bytes()) / 2
+	for u := int64(1); ; u++ {
+		x, y, err := curve.mapToCurve(big.NewInt(u))
+		if err != nil {
+			continue
+		}
+		buf = make([]byte, 2*size)
+		x.FillBytes(buf[:size])
+		y.FillBytes(buf[size:])
+		p := aVRF.pairing.NewG1().SetBytes(buf)
+		if !aVRF.pairing.NewG1().PowBig(p, curve.r).Is1() {
+			return aVRF.pairing.NewG1, buf, true
+		}
+	}
+}
+
+func TestSetFromHash(t *testing.T) {
+	for _, name := range Backends() {
+		pairing := newTestVRF(t, "DY05", name).pairing
+		for _, newElement := range []func() Element{pairing.NewG1, pairing.NewG2, pairing.NewGT} {
+			a := newElement().SetFromHash([]byte("a"))
+			if !a.Equals(newElement().SetFromHash([]byte("a"))) {
+				t.Errorf("%s: SetFromHash is not deterministic", name)
+			}
+			if a.Equals(newElement().SetFromHash([]byte("b"))) || a.Is1() {
+				t.Errorf("%s: SetFromHash of a gives %v", name, a)
+			}
+		}
+	}
+}
+
+func TestBn256Set(t *testing.T) {
+	pairing := bn256Pairing{}
+	for _, newElement := range []func() Element{pairing.NewZr, pairing.NewG1, pairing.NewG2, pairing.NewGT} {
+		x := newElement().Rand()
+		copies := []Element{x, newElement().Set(x), newElement().Set(x)}
+		if a, b := bn(copies[0]), bn(copies[1]); a.p != nil && a.p == b.p || a.q != nil && a.q == b.q ||
+			a.t != nil && a.t == b.t || a.z != nil && a.z == b.z {
+			t.Errorf("Set shares the value of %v", x)
+		}
+		// Bytes normalizes points in place: copies must be usable concurrently.
+		want := x.String()
+		var wg sync.WaitGroup
+		for _, c := range copies {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got := c.String(); got != want {
+					t.Errorf("copy %s of %s", got, want)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Invert(0) does not panic")
+		}
+	}()
+	pairing.NewZr().Invert(pairing.NewZr())
+}
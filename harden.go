@@ -0,0 +1,71 @@
+package vrf
+
+import (
+	"errors"
+)
+
+// ****** Hardened Evaluation ******
+// pbc and GMP are not constant time, so Eval with WithHardened keeps the
+// secret key out of the direct reach of the slow paths:
+// * inversion: 1/a is computed as b/(a b) for a fresh random b, the
+//   inversion only sees the blinded a b
+// * exponentiation: base^e is computed as base^e1 . base^(e - e1) for a
+//   fresh random e1, each half alone is independent of e
+// * DOD03 chain: the exponent 1 + fx[i] (u[i] - 1) is computed for every
+//   position instead of branching on fx[i]
+// This narrows timing and cache leakage, it does not make the backends
+// constant time. Values and proofs are the same as without it.
+// WithSelfCheck guards against faults instead: the output is verified
+// against the public key before it is released, and dropped when that
+// fails.
+
+var ErrSelfCheck = errors.New("vrf: eval output failed its self-check")
+
+type evalOptions struct {
+	hardened  bool
+	selfCheck bool
+}
+
+type EvalOption func(*evalOptions)
+
+// WithHardened evaluates with blinding and exponent splitting.
+func WithHardened() EvalOption {
+	return func(o *evalOptions) {
+		o.hardened = true
+	}
+}
+
+// WithSelfCheck verifies the output before returning it, see TryEval.
+func WithSelfCheck() EvalOption {
+	return func(o *evalOptions) {
+		o.selfCheck = true
+	}
+}
+
+func newEvalOptions(opts []EvalOption) *evalOptions {
+	o := &evalOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// invert returns 1/a in Zr.
+func (aVRF *abstractVRF) invert(a Element, o *evalOptions) Element {
+	if !o.hardened {
+		return aVRF.pairing.NewZr().Invert(a)
+	}
+	b := aVRF.pairing.NewZr().Rand()
+	ab := aVRF.pairing.NewZr().Mul(a, b).ThenInvert()
+	return ab.ThenMul(b)
+}
+
+// powProof returns base^e for base in the proof group.
+func (aVRF *abstractVRF) powProof(base, e Element, o *evalOptions) Element {
+	if !o.hardened {
+		return aVRF.newProofElement().PowZn(base, e)
+	}
+	e1 := aVRF.pairing.NewZr().Rand()
+	e2 := aVRF.pairing.NewZr().Sub(e, e1)
+	return aVRF.newProofElement().PowZn(base, e1).ThenMul(aVRF.newProofElement().PowZn(base, e2))
+}
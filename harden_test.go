@@ -0,0 +1,59 @@
+package vrf
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestHardenedEval(t *testing.T) {
+	for _, typeVRF := range schemes {
+		aVRF := newTestVRF(t, typeVRF, "bn256")
+		for _, x := range []*big.Int{big.NewInt(0), big.NewInt(5), big.NewInt(1<<40 + 3)} {
+			value, proof := aVRF.Eval(x)
+			hardValue, hardProof := aVRF.Eval(x, WithHardened(), WithSelfCheck())
+			if !hardValue.Equals(value) || len(hardProof) != len(proof) {
+				t.Fatalf("%s: hardened output on %v differs", typeVRF, x)
+			}
+			for i := range proof {
+				if !hardProof[i].Equals(proof[i]) {
+					t.Errorf("%s: hardened proof on %v differs at %d", typeVRF, x, i)
+				}
+			}
+		}
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	x := big.NewInt(5)
+	faults := []struct {
+		name  string
+		fault func(aVRF *abstractVRF)
+		x     *big.Int
+		want  error
+	}{
+		{"none", func(*abstractVRF) {}, x, nil},
+		{"input", func(*abstractVRF) {}, big.NewInt(-1), ErrInputDomain},
+		{"secret key", func(aVRF *abstractVRF) {
+			for i := aVRF.secretStart(); i < len(aVRF.secKey); i++ {
+				aVRF.secKey[i] = aVRF.pairing.NewZr().Rand()
+			}
+		}, x, ErrSelfCheck},
+		{"destroyed", func(aVRF *abstractVRF) { aVRF.Destroy() }, x, ErrDestroyed},
+	}
+	for _, typeVRF := range schemes {
+		for _, tc := range faults {
+			aVRF := newTestVRF(t, typeVRF, "bn256")
+			tc.fault(aVRF)
+			value, proof, err := aVRF.TryEval(tc.x, WithSelfCheck())
+			if !errors.Is(err, tc.want) || (err == nil) != (value != nil && proof != nil) {
+				t.Errorf("%s, %s: %v, want %v", typeVRF, tc.name, err, tc.want)
+			}
+			if tc.want == ErrSelfCheck {
+				if value, proof, err := aVRF.TryEval(tc.x); err != nil || aVRF.Verify(tc.x, value, proof) {
+					t.Errorf("%s, %s: faulty output without WithSelfCheck: %v", typeVRF, tc.name, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package vrf
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/bn256"
+)
+
+// ****** Hash to Curve ******
+// HashToG1 and HashToZr follow RFC 9380 with SHA-256:
+// * expand_message_xmd stretches (msg, dst) to uniform bytes
+// * hash_to_field reduces them to elements of Fq (Zr), k = 128 extra bits
+// * map_to_curve is Shallue-van de Woestijne, which works for every short
+//   Weierstrass curve y^2 = x^3 + a x + b, so also for the Type A
+//   y^2 = x^3 + x (b = 0) and bn256 y^2 = x^3 + 3 (a = 0)
+// * hash_to_curve maps two field elements, adds the points and clears the
+//   cofactor h
+// The curve is read from the parameters; G1 is the curve over the base field
+// for every pbc type and for bn256. dst must be unique to the protocol and
+// its use, e.g. "MYAPP-V01-CS01-with-vrf_Type-A_XMD:SHA-256_SVDW_RO_".
+
+const hashToFieldK = 128
+
+var (
+	ErrExpandLength = errors.New("vrf: expand_message_xmd output too long")
+	errNotOnCurve   = errors.New("vrf: hash to curve needs a square root of a non-square")
+)
+
+// weierstrass is the G1 curve y^2 = x^3 + a x + b over Fq with a subgroup of
+// prime order r and cofactor h, and the SvdW constants for it.
+type weierstrass struct {
+	q, r, h, a, b  *big.Int
+	z              *big.Int
+	c1, c2, c3, c4 *big.Int
+}
+
+// HashToG1 hashes msg to G1 under the domain separation tag dst.
+func (aVRF *abstractVRF) HashToG1(dst, msg []byte) (Element, error) {
+	return hashToG1(aVRF.params, aVRF.pairing, dst, msg)
+}
+
+// HashToZr hashes msg to Zr under the domain separation tag dst.
+func (aVRF *abstractVRF) HashToZr(dst, msg []byte) (Element, error) {
+	return hashToZr(aVRF.params, aVRF.pairing, dst, msg)
+}
+
+func hashToG1(params PairingParams, pairing Pairing, dst, msg []byte) (Element, error) {
+	curve, err := curveOf(params)
+	if err != nil {
+		return nil, err
+	}
+	u, err := hashToField(msg, dst, 2, curve.q)
+	if err != nil {
+		return nil, err
+	}
+	// points are encoded x || y, each coordinate padded to half the size
+	size := len(pairing.NewG1().Bytes()) / 2
+	q := make([]Element, len(u))
+	for i := range u {
+		x, y, err := curve.mapToCurve(u[i])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 2*size)
+		x.FillBytes(buf[:size])
+		y.FillBytes(buf[size:])
+		q[i] = pairing.NewG1().SetBytes(buf)
+	}
+	p := pairing.NewG1().Mul(q[0], q[1])
+	if curve.h.Cmp(big.NewInt(1)) != 0 {
+		p = pairing.NewG1().PowBig(p, curve.h)
+	}
+	return p, nil
+}
+
+func hashToZr(params PairingParams, pairing Pairing, dst, msg []byte) (Element, error) {
+	curve, err := curveOf(params)
+	if err != nil {
+		return nil, err
+	}
+	u, err := hashToField(msg, dst, 1, curve.r)
+	if err != nil {
+		return nil, err
+	}
+	return pairing.NewZr().SetBig(u[0]), nil
+}
+
+// expandMessageXMD is expand_message_xmd of RFC 9380 5.3.1 with SHA-256.
+func expandMessageXMD(msg, dst []byte, n int) ([]byte, error) {
+	const bInBytes, sInBytes = sha256.Size, sha256.BlockSize
+	if len(dst) > 255 {
+		h := sha256.Sum256(append([]byte("H2C-OVERSIZE-DST-"), dst...))
+		dst = h[:]
+	}
+	ell := (n + bInBytes - 1) / bInBytes
+	if ell > 255 || n > 65535 {
+		return nil, ErrExpandLength
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, sInBytes))
+	h.Write(msg)
+	h.Write([]byte{byte(n >> 8), byte(n), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(dstPrime)
+	bi := h.Sum(nil)
+	out := append([]byte{}, bi...)
+	for i := 2; i <= ell; i++ {
+		h.Reset()
+		for j := range bi {
+			bi[j] ^= b0[j]
+		}
+		h.Write(bi)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:n], nil
+}
+
+// hashToField is hash_to_field of RFC 9380 5.2 for the prime field of
+// order p (m = 1).
+func hashToField(msg, dst []byte, count int, p *big.Int) ([]*big.Int, error) {
+	L := (p.BitLen() + hashToFieldK + 7) / 8
+	uniform, err := expandMessageXMD(msg, dst, count*L)
+	if err != nil {
+		return nil, err
+	}
+	u := make([]*big.Int, count)
+	for i := range u {
+		u[i] = new(big.Int).SetBytes(uniform[i*L : (i+1)*L])
+		u[i].Mod(u[i], p)
+	}
+	return u, nil
+}
+
+// curveOf reads the G1 curve from the text encoding of params.
+func curveOf(params PairingParams) (*weierstrass, error) {
+	fields := paramFields(params)
+	num := func(key string) *big.Int {
+		n, ok := new(big.Int).SetString(fields[key], 10)
+		if !ok {
+			return nil
+		}
+		return n
+	}
+	one, zero := big.NewInt(1), big.NewInt(0)
+
+	curve := &weierstrass{}
+	switch fields["type"] {
+	case "a":
+		curve.q, curve.r, curve.h, curve.a, curve.b = num("q"), num("r"), num("h"), one, zero
+	case "a1":
+		curve.q, curve.r, curve.h, curve.a, curve.b = num("p"), num("n"), num("l"), one, zero
+	case "d", "e", "g":
+		curve.q, curve.r, curve.h, curve.a, curve.b = num("q"), num("r"), num("h"), num("a"), num("b")
+	case "f":
+		curve.q, curve.r, curve.h, curve.a, curve.b = num("q"), num("r"), one, zero, num("b")
+	case "bn256":
+		curve.q, curve.r, curve.h, curve.a, curve.b = bn256P, bn256.Order, one, zero, big.NewInt(3)
+	default:
+		return nil, ErrUnknownParams
+	}
+	for _, n := range []*big.Int{curve.q, curve.r, curve.h, curve.a, curve.b} {
+		if n == nil {
+			return nil, ErrUnknownParams
+		}
+	}
+	curve.svdwConstants()
+	return curve, nil
+}
+
+func (curve *weierstrass) mod(x *big.Int) *big.Int {
+	return x.Mod(x, curve.q)
+}
+
+// g is the right hand side x^3 + a x + b.
+func (curve *weierstrass) g(x *big.Int) *big.Int {
+	gx := new(big.Int).Mul(x, x)
+	gx.Add(gx, curve.a).Mul(gx, x).Add(gx, curve.b)
+	return curve.mod(gx)
+}
+
+func (curve *weierstrass) isSquare(x *big.Int) bool {
+	return x.Sign() == 0 || big.Jacobi(x, curve.q) == 1
+}
+
+func (curve *weierstrass) inv(x *big.Int) *big.Int {
+	if x.Sign() == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).ModInverse(x, curve.q)
+}
+
+// svdwConstants picks Z with find_z_svdw (RFC 9380 H.1) and derives c1..c4
+// (6.6.1).
+func (curve *weierstrass) svdwConstants() {
+	three, four := big.NewInt(3), big.NewInt(4)
+	// t(Z) = 3 Z^2 + 4 a
+	t := func(z *big.Int) *big.Int {
+		tz := new(big.Int).Mul(z, z)
+		tz.Mul(tz, three).Add(tz, new(big.Int).Mul(four, curve.a))
+		return curve.mod(tz)
+	}
+	for ctr := int64(1); ; ctr++ {
+		for _, z := range []*big.Int{big.NewInt(ctr), curve.mod(big.NewInt(-ctr))} {
+			gz := curve.g(z)
+			if gz.Sign() == 0 {
+				continue
+			}
+			// h(Z) = -(3 Z^2 + 4 a) / (4 g(Z))
+			hz := new(big.Int).Neg(t(z))
+			hz.Mul(hz, curve.inv(new(big.Int).Mul(four, gz)))
+			hz = curve.mod(hz)
+			if hz.Sign() == 0 || !curve.isSquare(hz) {
+				continue
+			}
+			halfZ := new(big.Int).Neg(z)
+			halfZ.Mul(halfZ, curve.inv(big.NewInt(2)))
+			if !curve.isSquare(gz) && !curve.isSquare(curve.g(curve.mod(halfZ))) {
+				continue
+			}
+
+			curve.z = z
+			curve.c1 = gz
+			curve.c2 = curve.mod(halfZ)
+			c3 := new(big.Int).Neg(gz)
+			c3.Mul(c3, t(z))
+			curve.c3 = new(big.Int).ModSqrt(curve.mod(c3), curve.q)
+			if curve.c3.Bit(0) == 1 {
+				curve.c3.Sub(curve.q, curve.c3)
+			}
+			c4 := new(big.Int).Neg(new(big.Int).Mul(four, gz))
+			c4.Mul(c4, curve.inv(t(z)))
+			curve.c4 = curve.mod(c4)
+			return
+		}
+	}
+}
+
+// mapToCurve is map_to_curve_svdw (RFC 9380 6.6.1). It is not constant
+// time, the input is public.
+func (curve *weierstrass) mapToCurve(u *big.Int) (x, y *big.Int, err error) {
+	one := big.NewInt(1)
+	tv1 := new(big.Int).Mul(u, u)
+	tv1 = curve.mod(tv1.Mul(tv1, curve.c1))
+	tv2 := curve.mod(new(big.Int).Add(one, tv1))
+	tv1 = curve.mod(new(big.Int).Sub(one, tv1))
+	tv3 := curve.inv(curve.mod(new(big.Int).Mul(tv1, tv2)))
+	tv4 := new(big.Int).Mul(u, tv1)
+	tv4.Mul(tv4, tv3).Mul(tv4, curve.c3)
+	tv4 = curve.mod(tv4)
+
+	x1 := curve.mod(new(big.Int).Sub(curve.c2, tv4))
+	x2 := curve.mod(new(big.Int).Add(curve.c2, tv4))
+	x3 := new(big.Int).Mul(tv2, tv2)
+	x3.Mul(x3, tv3)
+	x3 = curve.mod(x3)
+	x3.Mul(x3, x3).Mul(x3, curve.c4).Add(x3, curve.z)
+	x3 = curve.mod(x3)
+
+	switch {
+	case curve.isSquare(curve.g(x1)):
+		x = x1
+	case curve.isSquare(curve.g(x2)):
+		x = x2
+	default:
+		x = x3
+	}
+	y = new(big.Int).ModSqrt(curve.g(x), curve.q)
+	if y == nil {
+		return nil, nil, errNotOnCurve
+	}
+	if u.Bit(0) != y.Bit(0) {
+		y.Sub(curve.q, y).Mod(y, curve.q)
+	}
+	return x, y, nil
+}
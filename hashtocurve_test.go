@@ -0,0 +1,78 @@
+package vrf
+
+import (
+	"encoding/hex"
+	"math/big"
+	"path"
+	"testing"
+)
+
+// RFC 9380 K.1, expand_message_xmd with SHA-256.
+func TestExpandMessageXMD(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	vectors := []struct {
+		msg  string
+		n    int
+		want string
+	}{
+		{"", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+		{"abcdef0123456789", 0x20, "eff31487c770a893cfb36f912fbfcbff40d5661771ca4b2cb4eafe524333f5c1"},
+		{"", 0x80, "af84c27ccfd45d41914fdff5df25293e221afc53d8ad2ac06d5e3e29485dadbee0d121587713a3e0dd4d5e69e93eb7cd4f5df4cd103e188cf60cb02edc3edf18eda8576c412b18ffb658e3dd6ec849469b979d444cf7b26911a08e63cf31f9dcc541708d3491184472c2c29bb749d4286b004ceb5ee6b9a7fa5b646c993f0ced"},
+	}
+	for _, v := range vectors {
+		out, err := expandMessageXMD([]byte(v.msg), dst, v.n)
+		if err != nil || hex.EncodeToString(out) != v.want {
+			t.Errorf("msg %q, len %d: %x, %v", v.msg, v.n, out, err)
+		}
+	}
+	if _, err := expandMessageXMD(nil, dst, 256*32); err != ErrExpandLength {
+		t.Errorf("ell > 255: %v", err)
+	}
+}
+
+func TestMapToCurve(t *testing.T) {
+	curves := map[string]PairingParams{}
+	for _, name := range []string{"a", "d159", "f", "bn256"} {
+		data, _ := presets.ReadFile(path.Join("params", name+".param"))
+		curves[name] = presetText(data)
+	}
+	for name, params := range curves {
+		curve, err := curveOf(params)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		u, _ := hashToField([]byte(name), []byte("QUUX-V01-CS02-with-map"), 8, curve.q)
+		for _, ui := range append(u, big.NewInt(0), big.NewInt(1)) {
+			x, y, err := curve.mapToCurve(ui)
+			if err != nil {
+				t.Errorf("%s: u = %v: %v", name, ui, err)
+				continue
+			}
+			if curve.mod(new(big.Int).Mul(y, y)).Cmp(curve.g(x)) != 0 {
+				t.Errorf("%s: u = %v maps off the curve", name, ui)
+			}
+			if ui.Bit(0) != y.Bit(0) {
+				t.Errorf("%s: u = %v: sgn0(y) != sgn0(u)", name, ui)
+			}
+		}
+	}
+}
+
+func TestHashToG1(t *testing.T) {
+	aVRF := newTestVRF(t, "DY05", "bn256")
+	dst := []byte("VRF-V01-CS01-with-test")
+	p, err := aVRF.HashToG1(dst, []byte("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := aVRF.HashToG1(dst, []byte("abc"))
+	other, _ := aVRF.HashToG1(dst, []byte("abd"))
+	if !p.Equals(again) || p.Equals(other) || p.Is1() {
+		t.Errorf("HashToG1 is not a deterministic hash: %v, %v, %v", p, again, other)
+	}
+	curve, _ := curveOf(aVRF.params)
+	if !aVRF.pairing.NewG1().PowBig(p, curve.r).Is1() {
+		t.Error("r P != O")
+	}
+}
@@ -0,0 +1,167 @@
+package vrf
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// ****** Key Proof ******
+// With WithKeyProof, Gen also proves knowledge of every exponent of the
+// public key, pk[i] = base^u[i] (batched Schnorr, Fiat-Shamir):
+// * DY05: base gk, (gk^r)
+// * BMR10: base gk, (gk^u[1], ..., gk^u[n])
+// * DOD03: base h, (h^u[1], ..., h^u[n])
+// Prove -> proof = (c, s[1], ..., s[n])
+//		k[i]: random
+//		R[i]: base^k[i]
+//		c: H(scheme, params, base, pk, R)
+//		s[i]: k[i] + c u[i]
+// Verify -> check c == H(scheme, params, base, pk, R)
+//		R[i]: base^s[i] / pk[i]^c
+// The proof of possession (possession.go) is the same proof with a context
+// string in the hash.
+
+const keyProofDST = "VRF-V01-KEYPROOF_XMD:SHA-256_RO_"
+
+// WithKeyProof makes Gen and GenKey prove the public key, see KeyProof.
+func WithKeyProof() GenOption {
+	return func(o *genOptions) {
+		o.keyProof = true
+	}
+}
+
+// KeyProof returns the proof of the public key made with WithKeyProof, nil
+// when there is none.
+func (aVRF *abstractVRF) KeyProof() []Element {
+	return aVRF.keyProof
+}
+
+func (aVRF *abstractVRF) MarshalKeyProof() []string {
+	var proof []string
+	for i := 0; i < len(aVRF.keyProof); i++ {
+		proof = append(proof, aVRF.keyProof[i].String())
+	}
+	return proof
+}
+
+func (aVRF *abstractVRF) UnMarshalKeyProof(proof []string) ([]Element, error) {
+	var proof1 []Element
+	for i := 0; i < len(proof); i++ {
+		element, ok := aVRF.pairing.NewZr().SetString(proof[i], 10)
+		if !ok {
+			return nil, ErrInvalidParams
+		}
+		proof1 = append(proof1, element)
+	}
+	return proof1, nil
+}
+
+// VerifyPublicKey checks proof, made with WithKeyProof, for the public key
+// pk under the params of aVRF.
+func (aVRF *abstractVRF) VerifyPublicKey(pk []Element, proof []Element) bool {
+	return aVRF.verifyKey(pk, proof, keyProofDST, nil)
+}
+
+// genKeyProof proves the new key when asked to by WithKeyProof.
+func (aVRF *abstractVRF) genKeyProof(o *genOptions, r io.Reader) error {
+	aVRF.keyProof = nil
+	if !o.keyProof {
+		return nil
+	}
+	proof, err := aVRF.proveKey(r, keyProofDST, nil)
+	if err != nil {
+		return err
+	}
+	aVRF.keyProof = proof
+	return nil
+}
+
+func (aVRF *abstractVRF) verifyKey(pk []Element, proof []Element, dst string, context []byte) bool {
+	pk, err := tryMapArray(pk, aVRF.newKeyElement)
+	if err != nil {
+		return false
+	}
+	if proof, err = tryMapArray(proof, aVRF.pairing.NewZr); err != nil {
+		return false
+	}
+
+	n := 1
+	if aVRF.typeVRF == "BMR10" || aVRF.typeVRF == "DOD03" {
+		n = aVRF.lCode + 1
+	}
+	if len(pk) != n {
+		return false
+	}
+	base, elements := aVRF.keyStatement(pk)
+	if base.Is1() || len(proof) != len(elements)+1 {
+		return false
+	}
+
+	c := proof[0]
+	var R []Element
+	for i := range elements {
+		ci := aVRF.newKeyElement().PowZn(elements[i], c).ThenInvert()
+		R = append(R, aVRF.newKeyElement().PowZn(base, proof[i+1]).ThenMul(ci))
+	}
+	c2, err := aVRF.keyChallenge(dst, context, base, elements, R)
+	return err == nil && c.Equals(c2)
+}
+
+func (aVRF *abstractVRF) proveKey(r io.Reader, dst string, context []byte) ([]Element, error) {
+	base, elements := aVRF.keyStatement(aVRF.pubKey)
+	u := aVRF.secKey[:1]
+	if aVRF.typeVRF == "BMR10" || aVRF.typeVRF == "DOD03" {
+		u = aVRF.secKey[1:]
+	}
+
+	var k, R []Element
+	for range elements {
+		ki, err := randZr(aVRF.pairing, r)
+		if err != nil {
+			return nil, err
+		}
+		k = append(k, ki)
+		R = append(R, aVRF.newKeyElement().PowZn(base, ki))
+	}
+	c, err := aVRF.keyChallenge(dst, context, base, elements, R)
+	if err != nil {
+		return nil, err
+	}
+	proof := []Element{c}
+	for i := range elements {
+		proof = append(proof, aVRF.pairing.NewZr().Mul(c, u[i]).ThenAdd(k[i]))
+	}
+	return proof, nil
+}
+
+// keyStatement returns the base and the elements of pk proven by the key
+// proof.
+func (aVRF *abstractVRF) keyStatement(pk []Element) (Element, []Element) {
+	switch aVRF.typeVRF {
+	case "BMR10":
+		return aVRF.gk, pk[1:]
+	case "DOD03":
+		return pk[0], pk[1:]
+	default:
+		return aVRF.gk, pk[:1]
+	}
+}
+
+func (aVRF *abstractVRF) keyChallenge(dst string, context []byte, base Element, pk, R []Element) (Element, error) {
+	var msg []byte
+	write := func(b []byte) {
+		msg = binary.BigEndian.AppendUint32(msg, uint32(len(b)))
+		msg = append(msg, b...)
+	}
+	write([]byte(aVRF.typeVRF))
+	write(context)
+	write([]byte(aVRF.params.String()))
+	write(aVRF.g.Bytes())
+	write(aVRF.gk.Bytes())
+	write(base.Bytes())
+	for i := range pk {
+		write(pk[i].Bytes())
+		write(R[i].Bytes())
+	}
+	return aVRF.HashToZr([]byte(dst), msg)
+}
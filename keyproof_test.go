@@ -0,0 +1,54 @@
+package vrf
+
+import "testing"
+
+func TestVerifyPublicKey(t *testing.T) {
+	for _, typeVRF := range schemes {
+		aVRF := newTestVRF(t, typeVRF, "bn256", WithKeyProof())
+		other := newTestVRF(t, typeVRF, "bn256", WithKeyProof())
+		pk, proof := aVRF.GetPubKey(), aVRF.KeyProof()
+		if proof == nil {
+			t.Fatalf("%s: WithKeyProof made no proof", typeVRF)
+		}
+		decoded, err := aVRF.UnMarshalKeyProof(aVRF.MarshalKeyProof())
+		if err != nil {
+			t.Fatal(err)
+		}
+		changedKey := append([]Element{}, pk...)
+		changedKey[len(pk)-1] = other.GetPubKey()[len(pk)-1]
+		changedProof := append([]Element{}, proof...)
+		changedProof[0] = aVRF.pairing.NewZr().Add(proof[0], aVRF.pairing.NewZr().Set1())
+
+		for _, tc := range []struct {
+			name  string
+			pk    []Element
+			proof []Element
+			valid bool
+		}{
+			{"valid", pk, proof, true},
+			{"unmarshaled", pk, decoded, true},
+			{"no proof", pk, nil, false},
+			{"short proof", pk, proof[1:], false},
+			{"other key", other.GetPubKey(), proof, false},
+			{"changed key", changedKey, proof, false},
+			{"changed challenge", pk, changedProof, false},
+			{"proof of possession", pk, mustProvePossession(t, aVRF), false},
+		} {
+			if aVRF.VerifyPublicKey(tc.pk, tc.proof) != tc.valid {
+				t.Errorf("%s, %s: VerifyPublicKey is %v", typeVRF, tc.name, !tc.valid)
+			}
+		}
+		if newTestVRF(t, typeVRF, "bn256").KeyProof() != nil {
+			t.Errorf("%s: key proof without WithKeyProof", typeVRF)
+		}
+	}
+}
+
+func mustProvePossession(t *testing.T, aVRF *abstractVRF) []Element {
+	t.Helper()
+	pop, err := aVRF.ProvePossession("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pop
+}
@@ -0,0 +1,320 @@
+package vrf
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ****** Keyring ******
+// A Keyring holds the keys of one params while they are rotated. Every key
+// has a key ID, the fingerprint of its scheme and serialized public key,
+// and a validity window [NotBefore, NotAfter), zero times for no bound.
+// * Eval picks the active key: the one with a secret key whose window holds
+//   the time, the latest NotBefore when windows overlap. Its proof carries
+//   the key ID.
+// * Verify picks the key named by the key ID of the proof. Keys stay
+//   verifiable after NotAfter until they are removed; VerifyAt also requires
+//   the key to be valid at a given time.
+// Snapshot and RestoreKeyring turn a keyring into JSON and back, with or
+// without the secret keys.
+
+const keyringVersion = 1
+
+var (
+	ErrKeyringParams = errors.New("vrf: key was made for other params")
+	ErrKeyWindow     = errors.New("vrf: key is not valid at that time")
+	ErrNoActiveKey   = errors.New("vrf: no active key")
+	ErrKeyringFormat = errors.New("vrf: invalid keyring snapshot")
+)
+
+// KeyID identifies the public key of aVRF: the hex SHA-256 of the length
+// prefixed scheme and MarshalPubKey fields.
+func (aVRF *abstractVRF) KeyID() string {
+	return fingerprint(append([]string{aVRF.typeVRF}, aVRF.MarshalPubKey()...))
+}
+
+// KeyInfo describes a key of a Keyring.
+type KeyInfo struct {
+	ID        string
+	Scheme    string
+	NotBefore time.Time
+	NotAfter  time.Time
+	Secret    bool // the keyring can Eval with it
+}
+
+// ValidAt tells whether t lies in the window of the key.
+func (info KeyInfo) ValidAt(t time.Time) bool {
+	return !t.Before(info.NotBefore) && (info.NotAfter.IsZero() || t.Before(info.NotAfter))
+}
+
+// KeyedProof is a proof together with the ID of the key that made it.
+type KeyedProof struct {
+	KeyID string
+	Proof []Element
+}
+
+type keyringEntry struct {
+	info KeyInfo
+	vrf  *abstractVRF
+}
+
+type Keyring struct {
+	params *Params
+
+	mu   sync.RWMutex
+	keys map[string]*keyringEntry
+}
+
+func NewKeyring(params *Params) *Keyring {
+	return &Keyring{
+		params: params,
+		keys:   map[string]*keyringEntry{},
+	}
+}
+
+// Add puts the key of aVRF in the keyring, valid from notBefore until
+// notAfter, and returns its key ID. A key without a secret key is only used
+// by Verify.
+func (kr *Keyring) Add(aVRF *abstractVRF, notBefore, notAfter time.Time) (string, error) {
+	if aVRF.Params().Fingerprint() != kr.params.Fingerprint() {
+		return "", ErrKeyringParams
+	}
+	if len(aVRF.pubKey) == 0 {
+		return "", ErrUnknownKey
+	}
+	if !notAfter.IsZero() && !notBefore.Before(notAfter) {
+		return "", ErrKeyWindow
+	}
+	info := KeyInfo{
+		ID:        aVRF.KeyID(),
+		Scheme:    aVRF.typeVRF,
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+		Secret:    aVRF.hasSecKey(),
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if _, ok := kr.keys[info.ID]; ok {
+		return "", ErrKeyRegistered
+	}
+	kr.keys[info.ID] = &keyringEntry{info: info, vrf: aVRF}
+	return info.ID, nil
+}
+
+// Retire ends the window of id at t, if it ends later.
+func (kr *Keyring) Retire(id string, t time.Time) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	entry, ok := kr.keys[id]
+	if !ok {
+		return ErrUnknownKey
+	}
+	if entry.info.NotAfter.IsZero() || t.Before(entry.info.NotAfter) {
+		entry.info.NotAfter = t
+	}
+	return nil
+}
+
+// Remove drops id from the keyring; its proofs no longer verify.
+func (kr *Keyring) Remove(id string) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if _, ok := kr.keys[id]; !ok {
+		return ErrUnknownKey
+	}
+	delete(kr.keys, id)
+	return nil
+}
+
+// Keys lists the keys of the keyring by NotBefore, then ID.
+func (kr *Keyring) Keys() []KeyInfo {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	var infos []KeyInfo
+	for _, entry := range kr.keys {
+		infos = append(infos, entry.info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].NotBefore.Equal(infos[j].NotBefore) {
+			return infos[i].NotBefore.Before(infos[j].NotBefore)
+		}
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
+
+// Key returns the VRF holding id.
+func (kr *Keyring) Key(id string) (*abstractVRF, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	entry, ok := kr.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return entry.vrf, nil
+}
+
+// Active returns the key Eval uses at t.
+func (kr *Keyring) Active(t time.Time) (KeyInfo, *abstractVRF, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	var active *keyringEntry
+	for _, entry := range kr.keys {
+		if !entry.info.Secret || !entry.info.ValidAt(t) {
+			continue
+		}
+		if active == nil || entry.info.NotBefore.After(active.info.NotBefore) ||
+			(entry.info.NotBefore.Equal(active.info.NotBefore) && entry.info.ID < active.info.ID) {
+			active = entry
+		}
+	}
+	if active == nil {
+		return KeyInfo{}, nil, ErrNoActiveKey
+	}
+	return active.info, active.vrf, nil
+}
+
+// Eval evaluates x with the key active now, see EvalAt.
+func (kr *Keyring) Eval(x *big.Int, opts ...EvalOption) (Element, *KeyedProof, error) {
+	return kr.EvalAt(time.Now(), x, opts...)
+}
+
+// EvalAt evaluates x with the key active at t.
+func (kr *Keyring) EvalAt(t time.Time, x *big.Int, opts ...EvalOption) (Element, *KeyedProof, error) {
+	info, aVRF, err := kr.Active(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, proof, err := aVRF.TryEval(x, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, &KeyedProof{KeyID: info.ID, Proof: proof}, nil
+}
+
+// Verify checks value and proof for x with the key named by the proof.
+func (kr *Keyring) Verify(x *big.Int, value Element, proof *KeyedProof) bool {
+	aVRF, err := kr.Key(proof.KeyID)
+	if err != nil {
+		return false
+	}
+	return aVRF.Verify(x, value, proof.Proof)
+}
+
+// VerifyAt is Verify for an output made at t: the key must be valid at t.
+func (kr *Keyring) VerifyAt(t time.Time, x *big.Int, value Element, proof *KeyedProof) bool {
+	kr.mu.RLock()
+	entry, ok := kr.keys[proof.KeyID]
+	kr.mu.RUnlock()
+	if !ok || !entry.info.ValidAt(t) {
+		return false
+	}
+	return entry.vrf.Verify(x, value, proof.Proof)
+}
+
+// ****** Snapshot ******
+//		{"version": 1, "params": <Params.Marshal>,
+//		 "keys": [{"id", "scheme", "not_before", "not_after", "l_in",
+//		           "input", "public_key", "secret_key"}, ...]}
+// secret_key is only written by Snapshot(true), in the clear; store such a
+// snapshot like a secret key.
+
+type KeyringSnapshot struct {
+	Version int                  `json:"version"`
+	Params  []string             `json:"params"`
+	Keys    []KeyringSnapshotKey `json:"keys"`
+}
+
+type KeyringSnapshotKey struct {
+	ID        string       `json:"id"`
+	Scheme    string       `json:"scheme"`
+	NotBefore time.Time    `json:"not_before"`
+	NotAfter  time.Time    `json:"not_after"`
+	LIn       int          `json:"l_in,omitempty"`
+	Input     *InputPolicy `json:"input,omitempty"`
+	PublicKey []string     `json:"public_key"`
+	SecretKey []string     `json:"secret_key,omitempty"`
+}
+
+// Snapshot copies the keyring into a KeyringSnapshot, with the secret keys
+// when secret is set.
+func (kr *Keyring) Snapshot(secret bool) *KeyringSnapshot {
+	snap := &KeyringSnapshot{Version: keyringVersion, Params: kr.params.Marshal()}
+	for _, info := range kr.Keys() {
+		aVRF, err := kr.Key(info.ID)
+		if err != nil {
+			// removed meanwhile
+			continue
+		}
+		key := KeyringSnapshotKey{
+			ID:        info.ID,
+			Scheme:    info.Scheme,
+			NotBefore: info.NotBefore,
+			NotAfter:  info.NotAfter,
+			LIn:       aVRF.lIn,
+			Input:     aVRF.input,
+			PublicKey: aVRF.MarshalPubKey(),
+		}
+		if secret && info.Secret {
+			key.SecretKey = aVRF.MarshalSecKey()
+		}
+		snap.Keys = append(snap.Keys, key)
+	}
+	return snap
+}
+
+// RestoreKeyring rebuilds the keyring of snap. Every key must match its ID.
+func RestoreKeyring(snap *KeyringSnapshot) (*Keyring, error) {
+	if snap.Version != keyringVersion {
+		return nil, ErrKeyringFormat
+	}
+	params, err := ParseParams(snap.Params)
+	if err != nil {
+		return nil, err
+	}
+	kr := NewKeyring(params)
+	for _, key := range snap.Keys {
+		aVRF, err := restoreKey(params, key)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := kr.Add(aVRF, key.NotBefore, key.NotAfter); err != nil {
+			return nil, err
+		}
+	}
+	return kr, nil
+}
+
+// restoreKey decodes one key of a snapshot.
+func restoreKey(params *Params, key KeyringSnapshotKey) (*abstractVRF, error) {
+	aVRF, err := NewVRF(key.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	if key.LIn > 0 {
+		aVRF.lIn = key.LIn
+	}
+	aVRF.UseParams(params)
+	aVRF.input = key.Input
+	if len(key.SecretKey) > 0 {
+		err = aVRF.UnMarshalSecKey(key.SecretKey)
+	} else {
+		err = aVRF.UnMarshalPubKey(key.PublicKey)
+	}
+	if err != nil {
+		return nil, ErrKeyringFormat
+	}
+	want := 1
+	if aVRF.secretStart() > 0 {
+		want = aVRF.lCode + 1
+	}
+	if len(aVRF.pubKey) != want || !slices.Equal(aVRF.MarshalPubKey(), key.PublicKey) || aVRF.KeyID() != key.ID {
+		return nil, ErrKeyringFormat
+	}
+	return aVRF, nil
+}
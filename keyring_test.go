@@ -0,0 +1,114 @@
+package vrf
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testKeyring holds a DY05 and a BMR10 key on shared bn256 params: the
+// first valid in January, the second from February on.
+func testKeyring(t *testing.T) (*Keyring, []*abstractVRF, time.Time) {
+	t.Helper()
+	backend, _ := LookupBackend("bn256")
+	params, err := NewParams(80, WithBackend(backend))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := jan.AddDate(0, 1, 0)
+	kr := NewKeyring(params)
+	var keys []*abstractVRF
+	for i, typeVRF := range []string{"DY05", "BMR10"} {
+		aVRF, _ := NewVRF(typeVRF)
+		aVRF.GenKey(params)
+		notBefore, notAfter := jan, feb
+		if i == 1 {
+			notBefore, notAfter = feb, time.Time{}
+		}
+		if _, err := kr.Add(aVRF, notBefore, notAfter); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, aVRF)
+	}
+	return kr, keys, jan
+}
+
+func TestKeyringRotation(t *testing.T) {
+	kr, keys, jan := testKeyring(t)
+	x := big.NewInt(9)
+	for _, tc := range []struct {
+		at  time.Time
+		key int
+	}{{jan, 0}, {jan.AddDate(0, 0, 30), 0}, {jan.AddDate(0, 1, 0), 1}, {jan.AddDate(1, 0, 0), 1}} {
+		value, proof, err := kr.EvalAt(tc.at, x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.KeyID != keys[tc.key].KeyID() || !kr.Verify(x, value, proof) || !kr.VerifyAt(tc.at, x, value, proof) {
+			t.Errorf("%v: not made or verified by key %d", tc.at, tc.key)
+		}
+	}
+	if _, _, err := kr.EvalAt(jan.Add(-time.Second), x); err != ErrNoActiveKey {
+		t.Errorf("before every window: %v", err)
+	}
+
+	value, proof, _ := kr.EvalAt(jan, x)
+	if kr.VerifyAt(jan.AddDate(0, 2, 0), x, value, proof) {
+		t.Error("proof of a retired key verifies outside its window")
+	}
+	if kr.Verify(big.NewInt(10), value, proof) {
+		t.Error("proof verifies for another input")
+	}
+	if _, err := kr.Add(keys[0], jan, time.Time{}); err != ErrKeyRegistered {
+		t.Errorf("key added twice: %v", err)
+	}
+	if err := kr.Remove(proof.KeyID); err != nil || kr.Verify(x, value, proof) {
+		t.Errorf("removed key still verifies: %v", err)
+	}
+}
+
+func TestKeyringSnapshot(t *testing.T) {
+	kr, _, jan := testKeyring(t)
+	x := big.NewInt(9)
+	value, proof, _ := kr.EvalAt(jan, x)
+
+	for _, secret := range []bool{false, true} {
+		data, err := json.Marshal(kr.Snapshot(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var snap KeyringSnapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			t.Fatal(err)
+		}
+		restored, err := RestoreKeyring(&snap)
+		if err != nil {
+			t.Fatalf("secret %v: %v", secret, err)
+		}
+		if !restored.VerifyAt(jan, x, value, proof) {
+			t.Errorf("secret %v: restored keyring does not verify", secret)
+		}
+		_, _, err = restored.EvalAt(jan, x)
+		if (err == nil) != secret {
+			t.Errorf("secret %v: restored keyring evaluates: %v", secret, err)
+		}
+	}
+
+	tampered := []func(snap *KeyringSnapshot){
+		func(snap *KeyringSnapshot) { snap.Version = 2 },
+		func(snap *KeyringSnapshot) { snap.Keys[0].ID = snap.Keys[1].ID },
+		func(snap *KeyringSnapshot) { snap.Keys[0].PublicKey = snap.Keys[1].PublicKey[:1] },
+		func(snap *KeyringSnapshot) { snap.Keys[1].PublicKey = snap.Keys[1].PublicKey[1:] },
+		func(snap *KeyringSnapshot) { snap.Keys[0].SecretKey = []string{"00"} },
+		func(snap *KeyringSnapshot) { snap.Keys[1].SecretKey[1] = snap.Keys[1].SecretKey[2] },
+	}
+	for i, tamper := range tampered {
+		snap := kr.Snapshot(true)
+		tamper(snap)
+		if _, err := RestoreKeyring(snap); err != ErrKeyringFormat {
+			t.Errorf("tamper %d: %v", i, err)
+		}
+	}
+}
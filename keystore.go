@@ -0,0 +1,230 @@
+package vrf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ****** Keystore ******
+// SaveKey writes the key pair of aVRF to a JSON file:
+//		{"version": 1, "scheme": "DY05", "params": <Params.Fingerprint>,
+//		 "l_in": lIn, "l_code": lCode, "input": <InputPolicy>,
+//		 "public_key": [...], "kdf": {...}, "cipher": {...},
+//		 "ciphertext": <hex>}
+// l_in and l_code are only written for BMR10/DOD03 keys, input only with an
+// input policy; files without them get the defaults of the scheme. LoadKey
+// rebuilds the key with them, so it evaluates as before it was saved.
+// The secret key (MarshalSecKey) is encrypted with AES-256-GCM under a key
+// derived from the passphrase with scrypt. Everything but the ciphertext is
+// plaintext, authenticated as the additional data of GCM.
+
+const keystoreVersion = 1
+
+var (
+	ErrKeystoreFormat     = errors.New("vrf: invalid keystore file")
+	ErrKeystorePassphrase = errors.New("vrf: wrong passphrase or corrupted keystore")
+	ErrKeystoreParams     = errors.New("vrf: keystore was made for other params")
+)
+
+type keystoreKDF struct {
+	Name string `json:"name"`
+	Salt string `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+type keystoreCipher struct {
+	Name  string `json:"name"`
+	Nonce string `json:"nonce"`
+}
+
+type keystoreHeader struct {
+	Version   int            `json:"version"`
+	Scheme    string         `json:"scheme"`
+	Params    string         `json:"params"`
+	LIn       int            `json:"l_in,omitempty"`
+	LCode     int            `json:"l_code,omitempty"`
+	Input     *InputPolicy   `json:"input,omitempty"`
+	PublicKey []string       `json:"public_key"`
+	KDF       keystoreKDF    `json:"kdf"`
+	Cipher    keystoreCipher `json:"cipher"`
+}
+
+type keystoreFile struct {
+	keystoreHeader
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Fingerprint identifies params: the hex SHA-256 of its length prefixed
+// Marshal fields.
+func (params *Params) Fingerprint() string {
+	return fingerprint(params.Marshal())
+}
+
+func fingerprint(fields []string) string {
+	h := sha256.New()
+	for _, field := range fields {
+		binary.Write(h, binary.BigEndian, uint32(len(field)))
+		h.Write([]byte(field))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SaveKey encrypts the key pair of aVRF under passphrase and writes it to
+// path, readable by the owner only.
+func (aVRF *abstractVRF) SaveKey(path string, passphrase []byte) error {
+	header := keystoreHeader{
+		Version:   keystoreVersion,
+		Scheme:    aVRF.typeVRF,
+		Params:    aVRF.Params().Fingerprint(),
+		Input:     aVRF.input,
+		PublicKey: aVRF.MarshalPubKey(),
+		KDF:       keystoreKDF{Name: "scrypt", N: 1 << 15, R: 8, P: 1},
+		Cipher:    keystoreCipher{Name: "aes-256-gcm"},
+	}
+	if aVRF.secretStart() > 0 {
+		header.LIn, header.LCode = aVRF.lIn, aVRF.lCode
+	}
+	salt := make([]byte, 32)
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	header.KDF.Salt = hex.EncodeToString(salt)
+	header.Cipher.Nonce = hex.EncodeToString(nonce)
+
+	aead, err := header.aead(passphrase)
+	if err != nil {
+		return err
+	}
+	aad, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(aVRF.MarshalSecKey())
+	if err != nil {
+		return err
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, aad)
+	clear(plaintext)
+
+	out, err := json.MarshalIndent(keystoreFile{header, hex.EncodeToString(ciphertext)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0600)
+}
+
+// LoadKey reads a key saved by SaveKey for params.
+func LoadKey(path string, passphrase []byte, params *Params) (*abstractVRF, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != keystoreVersion ||
+		file.KDF.Name != "scrypt" || file.Cipher.Name != "aes-256-gcm" ||
+		file.KDF.N > 1<<20 || file.KDF.R*file.KDF.P > 64 {
+		return nil, ErrKeystoreFormat
+	}
+	if file.Params != params.Fingerprint() {
+		return nil, ErrKeystoreParams
+	}
+	nonce, err1 := hex.DecodeString(file.Cipher.Nonce)
+	ciphertext, err2 := hex.DecodeString(file.Ciphertext)
+	if err1 != nil || err2 != nil {
+		return nil, ErrKeystoreFormat
+	}
+
+	aead, err := file.keystoreHeader.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, ErrKeystoreFormat
+	}
+	aad, err := json.Marshal(file.keystoreHeader)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrKeystorePassphrase
+	}
+	var secKey []string
+	err = json.Unmarshal(plaintext, &secKey)
+	clear(plaintext)
+	if err != nil {
+		return nil, ErrKeystoreFormat
+	}
+
+	return file.keystoreHeader.restore(params, secKey)
+}
+
+// restore rebuilds the key of header from secKey. A key that does not fit
+// the scheme, its lengths or the public key is ErrKeystoreFormat.
+func (header *keystoreHeader) restore(params *Params, secKey []string) (*abstractVRF, error) {
+	var opts []Option
+	if header.LIn != 0 || header.LCode != 0 {
+		opts = append(opts, WithCode(header.LIn, header.LCode))
+	}
+	aVRF, err := NewVRF(header.Scheme, opts...)
+	if err != nil {
+		if errors.Is(err, ErrInvalidOption) {
+			return nil, ErrKeystoreFormat
+		}
+		return nil, err
+	}
+	if header.Input != nil && header.Input.Bits < 0 {
+		return nil, ErrKeystoreFormat
+	}
+	aVRF.input = header.Input
+	aVRF.UseParams(params)
+
+	want := 1
+	if aVRF.secretStart() > 0 {
+		want = aVRF.lCode + 1
+	}
+	if len(secKey) != want || len(header.PublicKey) != want {
+		return nil, ErrKeystoreFormat
+	}
+	if aVRF.UnMarshalSecKey(secKey) != nil {
+		return nil, ErrKeystoreFormat
+	}
+	if !slices.Equal(aVRF.MarshalPubKey(), header.PublicKey) {
+		aVRF.Destroy()
+		return nil, ErrKeystoreFormat
+	}
+	return aVRF, nil
+}
+
+func (header *keystoreHeader) aead(passphrase []byte) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(header.KDF.Salt)
+	if err != nil {
+		return nil, ErrKeystoreFormat
+	}
+	key, err := scrypt.Key(passphrase, salt, header.KDF.N, header.KDF.R, header.KDF.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,103 @@
+package vrf
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	passphrase := []byte("correct horse")
+	for _, typeVRF := range schemes {
+		backend, _ := LookupBackend("bn256")
+		aVRF, err := NewVRF(typeVRF, WithBackend(backend), WithInputBits(32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := aVRF.GenContext(context.Background(), 80); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "key.json")
+		if err := aVRF.SaveKey(path, passphrase); err != nil {
+			t.Fatal(err)
+		}
+		var header struct {
+			Input map[string]any `json:"input"`
+		}
+		if data, err := os.ReadFile(path); err != nil || json.Unmarshal(data, &header) != nil {
+			t.Fatal(err)
+		}
+		if typeVRF == "DY05" && (header.Input["bits"] != 32.0 || header.Input["hash"] != false) {
+			t.Errorf("%s: input policy written as %v", typeVRF, header.Input)
+		}
+		loaded, err := LoadKey(path, passphrase, aVRF.Params())
+		if err != nil {
+			t.Fatalf("%s: %v", typeVRF, err)
+		}
+		x := big.NewInt(7)
+		value, proof := aVRF.Eval(x)
+		loadedValue, loadedProof := loaded.Eval(x)
+		if !value.Equals(loadedValue) || !aVRF.Verify(x, loadedValue, loadedProof) || !loaded.Verify(x, value, proof) {
+			t.Errorf("%s: loaded key evaluates differently", typeVRF)
+		}
+	}
+}
+
+func TestKeystoreTamper(t *testing.T) {
+	passphrase := []byte("correct horse")
+	aVRF := newTestVRF(t, "BMR10", "bn256")
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := aVRF.SaveKey(path, passphrase); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("keystore mode %v", info.Mode())
+	}
+	if _, err := LoadKey(path, []byte("wrong"), aVRF.Params()); err != ErrKeystorePassphrase {
+		t.Errorf("wrong passphrase: %v", err)
+	}
+	if _, err := LoadKey(path, passphrase, newTestVRF(t, "BMR10", "bn256").Params()); err != ErrKeystoreParams {
+		t.Errorf("other params: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	tampered := []struct {
+		name string
+		edit func(file map[string]any)
+		want error
+	}{
+		{"scheme", func(file map[string]any) { file["scheme"] = "DOD03" }, ErrKeystorePassphrase},
+		{"l_code", func(file map[string]any) { file["l_code"] = 72.0 }, ErrKeystorePassphrase},
+		{"public key", func(file map[string]any) {
+			file["public_key"].([]any)[0] = file["public_key"].([]any)[1]
+		}, ErrKeystorePassphrase},
+		{"ciphertext", func(file map[string]any) {
+			c := file["ciphertext"].(string)
+			flip := map[byte]string{'0': "1"}[c[0]]
+			if flip == "" {
+				flip = "0"
+			}
+			file["ciphertext"] = flip + c[1:]
+		}, ErrKeystorePassphrase},
+		{"version", func(file map[string]any) { file["version"] = 2.0 }, ErrKeystoreFormat},
+		{"kdf cost", func(file map[string]any) { file["kdf"].(map[string]any)["n"] = float64(1 << 30) }, ErrKeystoreFormat},
+		{"nonce", func(file map[string]any) { file["cipher"].(map[string]any)["nonce"] = "00" }, ErrKeystoreFormat},
+	}
+	for _, tc := range tampered {
+		var file map[string]any
+		if err := json.Unmarshal(data, &file); err != nil {
+			t.Fatal(err)
+		}
+		tc.edit(file)
+		out, _ := json.Marshal(file)
+		if err := os.WriteFile(path, out, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadKey(path, passphrase, aVRF.Params()); err != tc.want {
+			t.Errorf("%s: %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package vrf
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// ****** Labels ******
+// A key used by several protocols must not give the same output to all of
+// them. LabeledEval and LabeledVerify bind a protocol label and version into
+// the input: the input of the scheme is
+//		expand_message_xmd(len(protocol) || protocol || version || input, LabelDST)
+// cut to the input domain of the scheme, lIn bits for BMR10/DOD03, the
+// input policy bits (default log2(r) - 1) for DY05. An output made for one
+// label verifies under another label only when both inputs are cut to the
+// same scheme input, which takes about 2^(bits/2) hashes. So the domain must
+// have twice the security level of the params in bits, or log2(r) - 1 when
+// that is less (the group gives no more); smaller domains, like the 64-bit
+// BMR10/DOD03 default, are refused with ErrLabelDomain. Use WithInputBits,
+// e.g. 160 on level 80 params.
+
+const LabelDST = "VRF-V01-LABEL_XMD:SHA-256_"
+
+// Label names the protocol, and its version, an output is made for.
+type Label struct {
+	Protocol string
+	Version  uint32
+}
+
+var ErrLabelDomain = errors.New("vrf: input domain too small for labeled inputs")
+
+// LabeledInput returns the input of the scheme for input under label, or
+// ErrLabelDomain when the input domain is too small, see above.
+func (aVRF *abstractVRF) LabeledInput(label Label, input []byte) (*big.Int, error) {
+	info, err := ParamsInfoOf(aVRF.params)
+	if err != nil {
+		return nil, err
+	}
+	bits := aVRF.lIn
+	if aVRF.typeVRF != "BMR10" && aVRF.typeVRF != "DOD03" {
+		bits = info.RBits - 1
+		if aVRF.input != nil && aVRF.input.Bits > 0 {
+			bits = aVRF.input.Bits
+		}
+	}
+	if bits < min(2*int(max(securityLevel(info), 80)), info.RBits-1) {
+		return nil, ErrLabelDomain
+	}
+
+	var msg []byte
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(label.Protocol)))
+	msg = append(msg, label.Protocol...)
+	msg = binary.BigEndian.AppendUint32(msg, label.Version)
+	msg = append(msg, input...)
+	uniform, err := expandMessageXMD(msg, []byte(LabelDST), (bits+7)/8+16)
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Int).SetBytes(uniform)
+	return x.Mod(x, new(big.Int).Lsh(big.NewInt(1), uint(bits))), nil
+}
+
+// LabeledEval evaluates the VRF on input under label, see TryEval.
+func (aVRF *abstractVRF) LabeledEval(label Label, input []byte, opts ...EvalOption) (Element, []Element, error) {
+	x, err := aVRF.LabeledInput(label, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aVRF.TryEval(x, opts...)
+}
+
+// LabeledVerify checks an output of LabeledEval for input under label.
+func (aVRF *abstractVRF) LabeledVerify(label Label, input []byte, y Element, proof []Element) bool {
+	x, err := aVRF.LabeledInput(label, input)
+	if err != nil {
+		return false
+	}
+	return aVRF.Verify(x, y, proof)
+}
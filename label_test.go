@@ -0,0 +1,46 @@
+package vrf
+
+import (
+	"testing"
+)
+
+func TestLabeledDomain(t *testing.T) {
+	domains := []struct {
+		typeVRF string
+		opts    []Option
+		want    error
+	}{
+		{"DY05", nil, nil},
+		{"DY05", []Option{WithInputBits(160)}, nil},
+		{"DY05", []Option{WithInputBits(64)}, ErrLabelDomain},
+		{"BMR10", nil, ErrLabelDomain},
+		{"BMR10", []Option{WithInputBits(160)}, nil},
+		{"DOD03", nil, ErrLabelDomain},
+		{"DOD03", []Option{WithCode(159, codeLength(159))}, ErrLabelDomain},
+		{"DOD03", []Option{WithCode(160, codeLength(160))}, nil},
+	}
+	for _, domain := range domains {
+		aVRF := newTestVRF(t, domain.typeVRF, "bn256", domain.opts...)
+		if _, err := aVRF.LabeledInput(Label{"lottery", 1}, []byte("round 1")); err != domain.want {
+			t.Errorf("%s, %d input bits: %v, want %v", domain.typeVRF, aVRF.lIn, err, domain.want)
+		}
+	}
+}
+
+func TestLabeledEval(t *testing.T) {
+	input := []byte("round 1")
+	lottery, committee := Label{"lottery", 1}, Label{"committee", 1}
+	for _, typeVRF := range schemes {
+		aVRF := newTestVRF(t, typeVRF, "bn256", WithInputBits(160))
+		value, proof, err := aVRF.LabeledEval(lottery, input)
+		if err != nil {
+			t.Fatalf("%s: %v", typeVRF, err)
+		}
+		if !aVRF.LabeledVerify(lottery, input, value, proof) {
+			t.Errorf("%s: labeled output does not verify", typeVRF)
+		}
+		if aVRF.LabeledVerify(committee, input, value, proof) || aVRF.LabeledVerify(Label{"lottery", 2}, input, value, proof) {
+			t.Errorf("%s: output verifies under another label", typeVRF)
+		}
+	}
+}
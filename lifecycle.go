@@ -0,0 +1,126 @@
+package vrf
+
+import (
+	"errors"
+)
+
+// ****** Secret Key Lifecycle ******
+// * GetSecKey hands out copies, the elements of aVRF stay private
+// * Destroy wipes the secret key: the exponents are overwritten in place
+//   where the backend allows it (Zeroizer), then dropped. h of BMR10/DOD03
+//   is part of the public key and is kept.
+// * LockSecKey moves the exponents to a buffer locked in memory (mlock on
+//   Linux), so they are never swapped out. They are decoded into elements
+//   only for the duration of an Eval, GetSecKey or MarshalSecKey and wiped
+//   right after.
+
+var (
+	ErrLockedMemory = errors.New("vrf: locked memory not supported on this platform")
+	ErrDestroyed    = errors.New("vrf: no secret key, destroyed or never set")
+)
+
+// Zeroizer is implemented by elements that can overwrite their own memory.
+type Zeroizer interface {
+	Zeroize()
+}
+
+func zeroize(el Element) {
+	if z, ok := el.(Zeroizer); ok {
+		z.Zeroize()
+		return
+	}
+	el.Set0()
+}
+
+// secretStart is the index of the first secret exponent of secKey.
+func (aVRF *abstractVRF) secretStart() int {
+	if aVRF.typeVRF == "BMR10" || aVRF.typeVRF == "DOD03" {
+		return 1
+	}
+	return 0
+}
+
+// pubKeyLength is the number of elements of a public key of the scheme.
+func (aVRF *abstractVRF) pubKeyLength() int {
+	if aVRF.secretStart() > 0 {
+		return aVRF.lCode + 1
+	}
+	return 1
+}
+
+// hasSecKey tells whether aVRF holds a secret key, locked or not.
+func (aVRF *abstractVRF) hasSecKey() bool {
+	return aVRF.lockedKey != nil || len(aVRF.secKey) > aVRF.secretStart()
+}
+
+// Destroy wipes the secret key of aVRF. It can no longer Eval.
+func (aVRF *abstractVRF) Destroy() {
+	aVRF.keyMu.Lock()
+	defer aVRF.keyMu.Unlock()
+	for i := aVRF.secretStart(); i < len(aVRF.secKey); i++ {
+		zeroize(aVRF.secKey[i])
+	}
+	aVRF.secKey = nil
+	if aVRF.lockedKey != nil {
+		aVRF.lockedKey.free()
+		aVRF.lockedKey = nil
+	}
+}
+
+// LockSecKey moves the secret exponents into locked memory. Without a secret
+// key it returns ErrDestroyed.
+func (aVRF *abstractVRF) LockSecKey() error {
+	aVRF.keyMu.Lock()
+	defer aVRF.keyMu.Unlock()
+	if !aVRF.hasSecKey() {
+		return ErrDestroyed
+	}
+	if aVRF.lockedKey != nil {
+		return nil
+	}
+	start := aVRF.secretStart()
+	size := len(aVRF.pairing.NewZr().Bytes())
+	buf, err := newLockedBuffer(size * (len(aVRF.secKey) - start))
+	if err != nil {
+		return err
+	}
+	for i := start; i < len(aVRF.secKey); i++ {
+		b := aVRF.secKey[i].Bytes()
+		copy(buf.data[(i-start)*size:], b)
+		clear(b)
+		zeroize(aVRF.secKey[i])
+	}
+	aVRF.secKey = aVRF.secKey[:start]
+	aVRF.lockedKey = buf
+	return nil
+}
+
+// withSecKey runs f with the secret key decoded from locked memory, if it is
+// locked. The decoded key lives in secKey while f runs, so calls are
+// serialized: f must not call withSecKey again. Without a secret key f is not
+// run and ErrDestroyed is returned.
+func (aVRF *abstractVRF) withSecKey(f func()) error {
+	aVRF.keyMu.Lock()
+	defer aVRF.keyMu.Unlock()
+	if !aVRF.hasSecKey() {
+		return ErrDestroyed
+	}
+	if aVRF.lockedKey == nil {
+		f()
+		return nil
+	}
+	start := aVRF.secretStart()
+	size := len(aVRF.pairing.NewZr().Bytes())
+	data := aVRF.lockedKey.data
+	for i := 0; i < len(data); i += size {
+		aVRF.secKey = append(aVRF.secKey, aVRF.pairing.NewZr().SetBytes(data[i:i+size]))
+	}
+	defer func() {
+		for i := start; i < len(aVRF.secKey); i++ {
+			zeroize(aVRF.secKey[i])
+		}
+		aVRF.secKey = aVRF.secKey[:start]
+	}()
+	f()
+	return nil
+}
@@ -0,0 +1,59 @@
+package vrf
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDestroy(t *testing.T) {
+	x := big.NewInt(11)
+	for _, typeVRF := range schemes {
+		aVRF := newTestVRF(t, typeVRF, "bn256")
+		value, proof := aVRF.Eval(x)
+		aVRF.Destroy()
+		if _, _, err := aVRF.TryEval(x); err != ErrDestroyed {
+			t.Errorf("%s: Eval after Destroy: %v", typeVRF, err)
+		}
+		if aVRF.GetSecKey() != nil || aVRF.MarshalSecKey() != nil {
+			t.Errorf("%s: secret key left after Destroy", typeVRF)
+		}
+		if !aVRF.Verify(x, value, proof) {
+			t.Errorf("%s: public key lost by Destroy", typeVRF)
+		}
+	}
+}
+
+func TestLockSecKey(t *testing.T) {
+	x := big.NewInt(11)
+	for _, typeVRF := range schemes {
+		verifier, _ := NewVRF(typeVRF)
+		if err := verifier.LockSecKey(); err != ErrDestroyed {
+			t.Errorf("%s: LockSecKey without a key: %v", typeVRF, err)
+		}
+
+		aVRF := newTestVRF(t, typeVRF, "bn256")
+		value, _ := aVRF.Eval(x)
+		secKey := aVRF.MarshalSecKey()
+		if err := aVRF.LockSecKey(); err != nil {
+			t.Skipf("no locked memory: %v", err)
+		}
+		if err := aVRF.LockSecKey(); err != nil {
+			t.Errorf("%s: second LockSecKey: %v", typeVRF, err)
+		}
+		lockedValue, lockedProof := aVRF.Eval(x)
+		if !lockedValue.Equals(value) || !aVRF.Verify(x, lockedValue, lockedProof) {
+			t.Errorf("%s: locked key evaluates differently", typeVRF)
+		}
+		if got := aVRF.MarshalSecKey(); len(got) != len(secKey) || got[len(got)-1] != secKey[len(secKey)-1] {
+			t.Errorf("%s: locked key marshals to %v", typeVRF, got)
+		}
+
+		aVRF.Destroy()
+		if err := aVRF.LockSecKey(); err != ErrDestroyed {
+			t.Errorf("%s: LockSecKey after Destroy: %v", typeVRF, err)
+		}
+		if _, _, err := aVRF.TryEval(x); err != ErrDestroyed {
+			t.Errorf("%s: Eval after LockSecKey and Destroy: %v", typeVRF, err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package vrf
+
+import (
+	"syscall"
+)
+
+// lockedBuffer is anonymous memory outside the Go heap, locked with mlock.
+type lockedBuffer struct {
+	data []byte
+}
+
+func newLockedBuffer(n int) (*lockedBuffer, error) {
+	data, err := syscall.Mmap(-1, 0, max(n, 1), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Mlock(data); err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+	return &lockedBuffer{data: data[:n]}, nil
+}
+
+func (buf *lockedBuffer) free() {
+	data := buf.data[:cap(buf.data)]
+	clear(data)
+	syscall.Munlock(data)
+	syscall.Munmap(data)
+	buf.data = nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package vrf
+
+type lockedBuffer struct {
+	data []byte
+}
+
+func newLockedBuffer(n int) (*lockedBuffer, error) {
+	return nil, ErrLockedMemory
+}
+
+func (buf *lockedBuffer) free() {}
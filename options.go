@@ -0,0 +1,95 @@
+package vrf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ****** Options ******
+// NewVRF takes the same options as Gen; the ones for parameter and key
+// generation (WithCurve, WithRand, WithBackend, ...) become the defaults of
+// every Gen of the VRF. A few only make sense on NewVRF:
+// * WithInputBits(k): the input domain [0, 2^k). lIn for BMR10/DOD03, with
+//   lCode following from the Hamming code; the input policy for DY05.
+// * WithCode(lIn, lCode): lIn and lCode of BMR10/DOD03, lCode must be the
+//   Hamming code length of lIn.
+// * WithHash: hash inputs into the domain instead of refusing the ones
+//   outside of it, see InputPolicy.
+// Combinations that cannot work are refused by NewVRF with ErrInvalidOption.
+
+type Option = GenOption
+
+var (
+	ErrUnknownScheme = errors.New("vrf: unknown scheme")
+	ErrInvalidOption = errors.New("vrf: invalid option")
+)
+
+// WithInputBits sets the input domain to [0, 2^k).
+func WithInputBits(k int) Option {
+	return func(o *genOptions) {
+		o.inputBits = k
+	}
+}
+
+// WithCode sets the code lengths of BMR10 and DOD03.
+func WithCode(lIn, lCode int) Option {
+	return func(o *genOptions) {
+		o.lIn, o.lCode = lIn, lCode
+	}
+}
+
+// WithHash hashes inputs into the input domain.
+func WithHash() Option {
+	return func(o *genOptions) {
+		o.hash = true
+	}
+}
+
+// codeLength is the Hamming code length of lIn bits.
+func codeLength(lIn int) int {
+	return len(HCode(strings.Repeat("0", lIn)))
+}
+
+func NewVRF(scheme string, opts ...Option) (*abstractVRF, error) {
+	if scheme == "" {
+		scheme = "DY05"
+	}
+	coded := scheme == "BMR10" || scheme == "DOD03"
+	if !coded && scheme != "DY05" {
+		return nil, ErrUnknownScheme
+	}
+	aVRF := &abstractVRF{typeVRF: scheme, opts: opts}
+	o := newGenOptions(opts)
+
+	switch {
+	case o.inputBits < 0 || o.lIn < 0 || o.lCode < 0:
+		return nil, fmt.Errorf("%w: negative length", ErrInvalidOption)
+	case o.lIn > 0 && !coded:
+		return nil, fmt.Errorf("%w: WithCode is for BMR10 and DOD03", ErrInvalidOption)
+	case o.lIn > 0 && o.lCode != codeLength(o.lIn):
+		return nil, fmt.Errorf("%w: lCode of %d input bits is %d", ErrInvalidOption, o.lIn, codeLength(o.lIn))
+	case o.lIn > 0 && o.inputBits > 0 && o.inputBits != o.lIn:
+		return nil, fmt.Errorf("%w: WithInputBits and WithCode disagree", ErrInvalidOption)
+	case o.curve != "" && o.params != nil:
+		return nil, fmt.Errorf("%w: WithCurve and WithParams", ErrInvalidOption)
+	case o.rand != nil && o.seed != nil:
+		return nil, fmt.Errorf("%w: WithRand and WithSeed", ErrInvalidOption)
+	}
+
+	if coded {
+		aVRF.lIn = o.lIn
+		if o.inputBits > 0 {
+			aVRF.lIn = o.inputBits
+		}
+	}
+	if o.hash || (!coded && o.inputBits > 0) {
+		aVRF.input = &InputPolicy{Hash: o.hash}
+		if !coded {
+			aVRF.input.Bits = o.inputBits
+		}
+	}
+	aVRF.backend = o.backend
+	aVRF.placement = o.placement
+	return aVRF, nil
+}
@@ -0,0 +1,44 @@
+package vrf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNewVRFOptions(t *testing.T) {
+	for _, tc := range []struct {
+		scheme string
+		opts   []Option
+		err    error
+		lIn    int
+		input  *InputPolicy
+	}{
+		{"", nil, nil, 0, nil},
+		{"HW10", nil, ErrUnknownScheme, 0, nil},
+		{"DY05", []Option{WithInputBits(-1)}, ErrInvalidOption, 0, nil},
+		{"BMR10", []Option{WithCode(-1, 0)}, ErrInvalidOption, 0, nil},
+		{"DY05", []Option{WithCode(64, 71)}, ErrInvalidOption, 0, nil},
+		{"BMR10", []Option{WithCode(64, 72)}, ErrInvalidOption, 0, nil},
+		{"DOD03", []Option{WithCode(64, 71), WithInputBits(32)}, ErrInvalidOption, 0, nil},
+		{"DY05", []Option{WithCurve(CurveA), WithParams(presetText(""))}, ErrInvalidOption, 0, nil},
+		{"DY05", []Option{WithRand(bytes.NewReader(nil)), WithSeed([32]byte{})}, ErrInvalidOption, 0, nil},
+		{"BMR10", []Option{WithCode(32, codeLength(32))}, nil, 32, nil},
+		{"DOD03", []Option{WithInputBits(16), WithHash()}, nil, 16, &InputPolicy{Hash: true}},
+		{"DY05", []Option{WithInputBits(32)}, nil, 0, &InputPolicy{Bits: 32}},
+		{"DY05", []Option{WithHash()}, nil, 0, &InputPolicy{Hash: true}},
+	} {
+		aVRF, err := NewVRF(tc.scheme, tc.opts...)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s with %d options: %v, want %v", tc.scheme, len(tc.opts), err, tc.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if aVRF.lIn != tc.lIn || (aVRF.input == nil) != (tc.input == nil) ||
+			aVRF.input != nil && *aVRF.input != *tc.input {
+			t.Errorf("%s with %d options: lIn %d, input %v", tc.scheme, len(tc.opts), aVRF.lIn, aVRF.input)
+		}
+	}
+}
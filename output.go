@@ -0,0 +1,100 @@
+package vrf
+
+import (
+	"crypto/sha3"
+	"encoding/binary"
+	"io"
+	"math/big"
+)
+
+// ****** Output ******
+// Output is the value of an evaluation, as the prover and every verifier see
+// it. Expand derives any number of labeled sub-values from it with
+// SHAKE256:
+//		SHAKE256(OutputDST || len(label) || label || len(value) || value)
+// so that one proven output gives, for instance, a card order and bonus
+// rolls under different labels, with no further proof. Uniform, Float and
+// Bernoulli turn the output into numbers without bias, each from its own
+// stream.
+
+const OutputDST = "VRF-V01-OUTPUT-SHAKE256"
+
+type Output struct {
+	value []byte
+}
+
+// NewOutput wraps the value returned by Eval and checked by Verify.
+func NewOutput(value Element) *Output {
+	return &Output{value: value.Bytes()}
+}
+
+// Bytes returns the canonical encoding of the value.
+func (out *Output) Bytes() []byte {
+	return append([]byte{}, out.value...)
+}
+
+// Reader returns the stream of sub-randomness for label.
+func (out *Output) Reader(label string) io.Reader {
+	xof := sha3.NewSHAKE256()
+	xof.Write([]byte(OutputDST))
+	xof.Write(binary.BigEndian.AppendUint32(nil, uint32(len(label))))
+	xof.Write([]byte(label))
+	xof.Write(binary.BigEndian.AppendUint32(nil, uint32(len(out.value))))
+	xof.Write(out.value)
+	return xof
+}
+
+// Expand returns the first n bytes of the stream for label.
+func (out *Output) Expand(label string, n int) []byte {
+	buf := make([]byte, n)
+	io.ReadFull(out.Reader(label), buf)
+	return buf
+}
+
+// Sub returns an independent output for label, for use with the helpers
+// below, e.g. out.Sub("round 2").Uniform(n).
+func (out *Output) Sub(label string) *Output {
+	return &Output{value: out.Expand("sub:"+label, 64)}
+}
+
+// Uniform returns an integer in [0, n), without bias: k-bit candidates,
+// k the bit length of n - 1, are read from the "uniform" stream until one is
+// below n.
+func (out *Output) Uniform(n *big.Int) *big.Int {
+	if n.Sign() <= 0 {
+		panic("vrf: Uniform of n <= 0")
+	}
+	k := new(big.Int).Sub(n, big.NewInt(1)).BitLen()
+	r := out.Reader("uniform")
+	buf := make([]byte, (k+7)/8)
+	x := new(big.Int)
+	for {
+		io.ReadFull(r, buf)
+		if k%8 != 0 {
+			buf[0] &= byte(1)<<(k%8) - 1
+		}
+		if x.SetBytes(buf).Cmp(n) < 0 {
+			return x
+		}
+	}
+}
+
+// Float returns a float64 in [0, 1): a uniform multiple of 2^-53, every one
+// of which float64 represents exactly.
+func (out *Output) Float() float64 {
+	buf := out.Expand("float", 8)
+	return float64(binary.BigEndian.Uint64(buf)>>11) / (1 << 53)
+}
+
+// Bernoulli returns true with probability exactly p: a uniform integer
+// below the denominator of p is compared to its numerator.
+func (out *Output) Bernoulli(p *big.Rat) bool {
+	if p.Sign() <= 0 {
+		return false
+	}
+	if p.Cmp(big.NewRat(1, 1)) >= 0 {
+		return true
+	}
+	u := out.Sub("bernoulli").Uniform(p.Denom())
+	return u.Cmp(p.Num()) < 0
+}
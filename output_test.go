@@ -0,0 +1,111 @@
+package vrf
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"testing"
+)
+
+// testOutputs returns n outputs of distinct values.
+func testOutputs(n int) []*Output {
+	outs := make([]*Output, n)
+	for i := range outs {
+		outs[i] = NewOutput(bn256Pairing{}.NewZr().SetInt32(int32(i)))
+	}
+	return outs
+}
+
+func TestOutputExpand(t *testing.T) {
+	outs := testOutputs(2)
+	out := outs[0]
+	long := out.Expand("cards", 64)
+	stream := make([]byte, 64)
+	io.ReadFull(out.Reader("cards"), stream)
+
+	for _, tc := range []struct {
+		name  string
+		a, b  []byte
+		equal bool
+	}{
+		{"prefix", out.Expand("cards", 16), long[:16], true},
+		{"reader", stream, long, true},
+		{"deterministic", testOutputs(1)[0].Expand("cards", 64), long, true},
+		{"label", out.Expand("bonus", 64), long, false},
+		{"label boundary", out.Expand("card", 64), long, false},
+		{"value", outs[1].Expand("cards", 64), long, false},
+		{"sub", out.Sub("cards").Bytes(), long, false},
+		{"sub label", out.Sub("a").Bytes(), out.Sub("b").Bytes(), false},
+	} {
+		if bytes.Equal(tc.a, tc.b) != tc.equal {
+			t.Errorf("%s: streams equal %v", tc.name, !tc.equal)
+		}
+	}
+
+	b := out.Bytes()
+	b[0] ^= 1
+	if bytes.Equal(b, out.Bytes()) {
+		t.Error("Bytes shares the value")
+	}
+}
+
+func TestUniform(t *testing.T) {
+	outs := testOutputs(3000)
+	for _, n := range []*big.Int{
+		big.NewInt(1), big.NewInt(3), big.NewInt(256), big.NewInt(1000),
+		new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)),
+	} {
+		for _, out := range outs[:200] {
+			if u := out.Uniform(n); u.Sign() < 0 || u.Cmp(n) >= 0 {
+				t.Fatalf("Uniform(%v) = %v", n, u)
+			}
+		}
+	}
+
+	var counts [3]int
+	for _, out := range outs {
+		counts[out.Uniform(big.NewInt(3)).Int64()]++
+	}
+	for i, c := range counts {
+		if c < 900 || c > 1100 {
+			t.Errorf("Uniform(3) gives %d %d times out of 3000", i, c)
+		}
+	}
+	for _, out := range outs[:200] {
+		if f := out.Float(); f < 0 || f >= 1 {
+			t.Fatalf("Float() = %v", f)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Uniform(0) does not panic")
+		}
+	}()
+	outs[0].Uniform(big.NewInt(0))
+}
+
+func TestBernoulli(t *testing.T) {
+	outs := testOutputs(3000)
+	for _, tc := range []struct {
+		p        *big.Rat
+		min, max int
+	}{
+		{big.NewRat(-1, 2), 0, 0},
+		{big.NewRat(0, 1), 0, 0},
+		{big.NewRat(1, 3), 900, 1100},
+		{big.NewRat(1, 2), 1400, 1600},
+		{big.NewRat(1, 1), 3000, 3000},
+		{big.NewRat(3, 2), 3000, 3000},
+	} {
+		n := 0
+		for _, out := range outs {
+			if out.Bernoulli(tc.p) {
+				n++
+			}
+		}
+		if n < tc.min || n > tc.max {
+			t.Errorf("Bernoulli(%v) true %d times out of 3000", tc.p, n)
+		}
+	}
+}
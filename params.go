@@ -0,0 +1,369 @@
+package vrf
+
+import (
+	"errors"
+	"io"
+)
+
+// ****** Shared Parameters ******
+// Params is the public part of a key that does not depend on the key: the
+// pairing parameters, the placement of keys and proofs, and the generators.
+// With WithPublicSeed the generators, and h of BMR10/DOD03, are hashed from
+// a public seed so that anyone can check them with VerifyParams.
+// One Params can be published once and used by many keys, of any scheme:
+//		params, _ := NewParams(128)
+//		alice.GenKey(params)
+//		bob.GenKey(params)
+//		verifier.UseParams(params)
+type Params struct {
+	Pairing   PairingParams
+	Placement Placement
+	G         Element // generator of the proof group
+	GK        Element // generator of the key group, G when symmetric
+	Seed      string  // public seed of G, GK and H, "" when random
+	H         Element // h of BMR10/DOD03, nil when random
+}
+
+const (
+	generatorGDST  = "VRF-V01-GEN-G_XMD:SHA-256_SVDW_RO_"
+	generatorGKDST = "VRF-V01-GEN-GK_XMD:SHA-256_SVDW_RO_"
+	generatorHDST  = "VRF-V01-GEN-H_XMD:SHA-256_SVDW_RO_"
+)
+
+var (
+	ErrInvalidParams  = errors.New("vrf: invalid params")
+	ErrNoParamsSeed   = errors.New("vrf: params have no public seed")
+	ErrParamsMismatch = errors.New("vrf: params do not match their public seed")
+)
+
+// WithBackend generates the parameters with backend instead of
+// DefaultBackend.
+func WithBackend(backend Backend) GenOption {
+	return func(o *genOptions) {
+		o.backend = backend
+	}
+}
+
+// WithPolicy replaces the policy derived from the security level.
+func WithPolicy(policy SecurityPolicy) GenOption {
+	return func(o *genOptions) {
+		o.policy = &policy
+	}
+}
+
+// WithPublicSeed hashes the generators and h from seed instead of picking
+// them at random, so that nobody knows a discrete log between them. G1
+// elements are hashed to the curve, G2 elements with SetFromHash of the
+// backend over the expanded seed.
+func WithPublicSeed(seed string) GenOption {
+	return func(o *genOptions) {
+		o.publicSeed = seed
+	}
+}
+
+// WithPlacement puts public keys in the given group of an asymmetric
+// pairing.
+func WithPlacement(placement Placement) GenOption {
+	return func(o *genOptions) {
+		o.placement = placement
+	}
+}
+
+// NewParams generates (or, with WithParams, reuses) pairing parameters for
+// the security level lambda and picks fresh generators. Reused parameters
+// below the level are refused with ErrWeakParams.
+func NewParams(lambda uint32, opts ...GenOption) (*Params, error) {
+	o := newGenOptions(opts)
+	if o.backend == nil {
+		o.backend = DefaultBackend()
+	}
+	policy := o.policy
+	if policy == nil {
+		p, err := PolicyFor(lambda)
+		if err != nil {
+			return nil, err
+		}
+		policy = &p
+	}
+	pairingParams := o.params
+	if pairingParams == nil {
+		curve := o.curve
+		if curve == "" {
+			curve = o.backend.DefaultCurve(lambda)
+		}
+		rbits, qbits := policy.Sizes(curve)
+		var err error
+		pairingParams, err = o.run(func() (PairingParams, error) {
+			return o.backend.GenerateParams(curve, rbits, qbits, o.discriminant)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := CheckParams(pairingParams, *policy); err != nil {
+		return nil, err
+	}
+	if err := o.report(GenEvent{Stage: GenParams}); err != nil {
+		return nil, err
+	}
+
+	var err error
+	r := o.reader(seedParamsDST)
+	params := &Params{Pairing: pairingParams, Placement: o.placement}
+	pairing := pairingParams.NewPairing()
+	if o.publicSeed != "" {
+		params.Seed = o.publicSeed
+		if err := params.derive(pairing); err != nil {
+			return nil, err
+		}
+	} else if params.G, err = randElement(newProofElement(pairing, o.placement), r); err != nil {
+		return nil, err
+	} else if pairing.IsSymmetric() {
+		params.GK = params.G
+	} else if params.GK, err = randElement(newKeyElement(pairing, o.placement), r); err != nil {
+		return nil, err
+	}
+	if err := o.report(GenEvent{Stage: GenGenerator}); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// derive hashes G, GK and H from Seed, each in its group.
+func (params *Params) derive(pairing Pairing) error {
+	proofInG2 := !pairing.IsSymmetric() && params.Placement == PubKeyInG1
+	keyInG2 := !pairing.IsSymmetric() && params.Placement == PubKeyInG2
+	var err error
+	if params.G, err = hashToGroup(params.Pairing, pairing, proofInG2, []byte(generatorGDST), []byte(params.Seed)); err != nil {
+		return err
+	}
+	params.GK = params.G
+	if !pairing.IsSymmetric() {
+		if params.GK, err = hashToGroup(params.Pairing, pairing, keyInG2, []byte(generatorGKDST), []byte(params.Seed)); err != nil {
+			return err
+		}
+	}
+	params.H, err = hashToGroup(params.Pairing, pairing, keyInG2, []byte(generatorHDST), []byte(params.Seed))
+	return err
+}
+
+// hashToGroup hashes msg to G2 when g2 is set, to G1 otherwise. G2 is not a
+// curve over the base field, so there the backend maps the expanded message
+// with SetFromHash.
+func hashToGroup(params PairingParams, pairing Pairing, g2 bool, dst, msg []byte) (Element, error) {
+	if !g2 {
+		return hashToG1(params, pairing, dst, msg)
+	}
+	uniform, err := expandMessageXMD(msg, dst, 64)
+	if err != nil {
+		return nil, err
+	}
+	return pairing.NewG2().SetFromHash(uniform), nil
+}
+
+// VerifyParams recomputes the generators of params from their public seed.
+func VerifyParams(params *Params) error {
+	if params.Seed == "" {
+		return ErrNoParamsSeed
+	}
+	derived := &Params{Pairing: params.Pairing, Placement: params.Placement, Seed: params.Seed}
+	if err := derived.derive(params.Pairing.NewPairing()); err != nil {
+		return err
+	}
+	if !derived.G.Equals(params.G) || !derived.GK.Equals(params.GK) ||
+		(params.H != nil && !derived.H.Equals(params.H)) {
+		return ErrParamsMismatch
+	}
+	return nil
+}
+
+// VerifyParams checks the params of aVRF, and h in the public key of
+// BMR10/DOD03, against their public seed.
+func (aVRF *abstractVRF) VerifyParams() error {
+	params := aVRF.Params()
+	if err := VerifyParams(params); err != nil {
+		return err
+	}
+	if aVRF.typeVRF != "DY05" && len(aVRF.pubKey) > 0 {
+		derived := &Params{Pairing: params.Pairing, Placement: params.Placement, Seed: params.Seed}
+		if err := derived.derive(aVRF.pairing); err != nil {
+			return err
+		}
+		if !derived.H.Equals(aVRF.pubKey[0]) {
+			return ErrParamsMismatch
+		}
+	}
+	return nil
+}
+
+// Marshal encodes params in the layout of MarshalParams, with empty lengths.
+func (params *Params) Marshal() []string {
+	return []string{
+		params.Pairing.String(),
+		params.G.String(),
+		"",
+		"",
+		params.GK.String(),
+		params.Placement.String(),
+		params.Seed,
+	}
+}
+
+// ParseParams decodes the output of Params.Marshal or MarshalParams. The
+// older (params, g, lIn, lCode) layout is read as a symmetric pairing.
+// Parameter sets below ImportPolicy are refused. H is rederived from a public
+// seed, but G is not checked against it; that is up to VerifyParams.
+func ParseParams(allParams []string) (*Params, error) {
+	if len(allParams) < 2 {
+		return nil, ErrInvalidParams
+	}
+	pairingParams, err := ParsePairingParams(allParams[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckParams(pairingParams, ImportPolicy); err != nil {
+		return nil, err
+	}
+	params := &Params{Pairing: pairingParams}
+	if len(allParams) > 5 {
+		var ok bool
+		if params.Placement, ok = ParsePlacement(allParams[5]); !ok {
+			return nil, ErrInvalidParams
+		}
+	}
+
+	var ok bool
+	pairing := pairingParams.NewPairing()
+	if params.G, ok = newProofElement(pairing, params.Placement).SetString(allParams[1], 10); !ok {
+		return nil, ErrInvalidParams
+	}
+	params.GK = params.G
+	if len(allParams) > 4 && !pairing.IsSymmetric() {
+		if params.GK, ok = newKeyElement(pairing, params.Placement).SetString(allParams[4], 10); !ok {
+			return nil, ErrInvalidParams
+		}
+	}
+	if len(allParams) > 6 && allParams[6] != "" {
+		params.Seed = allParams[6]
+		derived := &Params{Pairing: pairingParams, Placement: params.Placement, Seed: params.Seed}
+		if err := derived.derive(pairing); err != nil {
+			return nil, err
+		}
+		params.H = derived.H
+	}
+	return params, nil
+}
+
+// Params returns the shared parameters of aVRF.
+func (aVRF *abstractVRF) Params() *Params {
+	return &Params{
+		Pairing:   aVRF.params,
+		Placement: aVRF.placement,
+		G:         aVRF.g,
+		GK:        aVRF.gk,
+		Seed:      aVRF.seed,
+		H:         aVRF.h,
+	}
+}
+
+// UseParams switches aVRF to params without generating keys, e.g. for a
+// verifier that then imports public keys. It also sets lIn and lCode for the
+// scheme.
+func (aVRF *abstractVRF) UseParams(params *Params) {
+	aVRF.params = params.Pairing
+	aVRF.pairing = params.Pairing.NewPairing()
+	aVRF.placement = params.Placement
+	aVRF.g = params.G
+	aVRF.gk = params.GK
+	aVRF.seed = params.Seed
+	aVRF.h = params.H
+	aVRF.SetLength()
+}
+
+// keyVRF returns a VRF of the scheme of aVRF on params, without keys, for
+// GenKey to generate into.
+func (aVRF *abstractVRF) keyVRF(params *Params) *abstractVRF {
+	key := &abstractVRF{
+		typeVRF: aVRF.typeVRF,
+		opts:    aVRF.opts,
+		input:   aVRF.input,
+		backend: aVRF.backend,
+		lIn:     aVRF.lIn,
+	}
+	key.UseParams(params)
+	return key
+}
+
+// setKeys wipes the secret key of aVRF and takes over the params and keys of
+// key, made by keyVRF.
+func (aVRF *abstractVRF) setKeys(key *abstractVRF) {
+	aVRF.Destroy()
+	aVRF.keyMu.Lock()
+	defer aVRF.keyMu.Unlock()
+	aVRF.params, aVRF.pairing, aVRF.placement = key.params, key.pairing, key.placement
+	aVRF.g, aVRF.gk, aVRF.seed, aVRF.h = key.g, key.gk, key.seed, key.h
+	aVRF.lIn, aVRF.lCode = key.lIn, key.lCode
+	aVRF.secKey, aVRF.pubKey, aVRF.keyProof = key.secKey, key.pubKey, key.keyProof
+}
+
+// keyH returns h for BMR10/DOD03: the one of the params when derived from a
+// public seed, a random one otherwise.
+func (aVRF *abstractVRF) keyH(r io.Reader) (Element, error) {
+	if aVRF.h != nil {
+		return aVRF.newKeyElement().Set(aVRF.h), nil
+	}
+	return randElement(aVRF.newKeyElement(), r)
+}
+
+// GenKey generates a key pair for params. WithRand, WithSeed, WithProgress
+// and WithKeyProof are the only options it looks at.
+func (aVRF *abstractVRF) GenKey(params *Params, opts ...GenOption) {
+	if aVRF.typeVRF == "" {
+		panic("...")
+	}
+	if err := aVRF.genKey(params, append(append([]GenOption{}, aVRF.opts...), opts...)); err != nil {
+		panic(err)
+	}
+}
+
+func (aVRF *abstractVRF) genKey(params *Params, opts []GenOption) error {
+	switch aVRF.typeVRF {
+	case "DY05":
+		return aVRF.DY05GenKey(params, opts...)
+	case "BMR10":
+		return aVRF.BMR10GenKey(params, opts...)
+	case "DOD03":
+		return aVRF.DOD03GenKey(params, opts...)
+	default:
+		return aVRF.DY05GenKey(params, opts...)
+	}
+}
+
+func (aVRF *abstractVRF) SetLength() {
+	if aVRF.lIn > 0 && (aVRF.typeVRF == "BMR10" || aVRF.typeVRF == "DOD03") {
+		// set by WithInputBits or WithCode
+		aVRF.lCode = codeLength(aVRF.lIn)
+		return
+	}
+	switch aVRF.typeVRF {
+	case "DY05":
+		aVRF.DY05SetLength()
+	case "BMR10":
+		aVRF.BMR10SetLength()
+	case "DOD03":
+		aVRF.DOD03SetLength()
+	default:
+		aVRF.DY05SetLength()
+	}
+}
+
+// genDefaults puts the options of NewVRF, and the backend and placement set
+// on aVRF, in front of opts.
+func (aVRF *abstractVRF) genDefaults(opts []GenOption) []GenOption {
+	defaults := append([]GenOption{}, aVRF.opts...)
+	defaults = append(defaults, WithPlacement(aVRF.placement))
+	if aVRF.backend != nil {
+		defaults = append(defaults, WithBackend(aVRF.backend))
+	}
+	return append(defaults, opts...)
+}
@@ -0,0 +1,134 @@
+package vrf
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPublicSeed(t *testing.T) {
+	x := big.NewInt(5)
+	for _, name := range Backends() {
+		for _, placement := range []Placement{PubKeyInG2, PubKeyInG1} {
+			for _, typeVRF := range schemes {
+				aVRF := newTestVRF(t, typeVRF, name, WithPublicSeed("public seed"), WithPlacement(placement))
+				if err := aVRF.VerifyParams(); err != nil {
+					t.Fatalf("%s on %s, keys in %v: %v", typeVRF, name, placement, err)
+				}
+				value, proof := aVRF.Eval(x)
+
+				verifier, _ := NewVRF(typeVRF)
+				if err := verifier.UnMarshalParams(aVRF.MarshalParams()); err != nil {
+					t.Fatal(err)
+				}
+				if err := verifier.UnMarshalPubKey(aVRF.MarshalPubKey()); err != nil {
+					t.Fatal(err)
+				}
+				if err := verifier.VerifyParams(); err != nil {
+					t.Errorf("%s on %s, keys in %v: imported params: %v", typeVRF, name, placement, err)
+				}
+				if !verifier.Verify(x, value, proof) {
+					t.Errorf("%s on %s, keys in %v: output does not verify", typeVRF, name, placement)
+				}
+			}
+		}
+	}
+}
+
+func TestUnMarshalParams(t *testing.T) {
+	aVRF := newTestVRF(t, "BMR10", "bn256")
+	allParams := aVRF.MarshalParams()
+
+	verifier, _ := NewVRF("BMR10")
+	allParams[3] = "70"
+	if err := verifier.UnMarshalParams(allParams); err != ErrInvalidParams {
+		t.Errorf("lCode 70 for 64 input bits: %v", err)
+	}
+	if verifier.params != nil {
+		t.Error("failed UnMarshalParams changed the VRF")
+	}
+	if err := verifier.UnMarshalParams([]string{"type x"}); err == nil {
+		t.Error("garbage params accepted")
+	}
+}
+
+func TestSetParams(t *testing.T) {
+	aVRF := newTestVRF(t, "BMR10", "bn256")
+	params, generator, lIn, lCode := aVRF.GetParams()
+	x := big.NewInt(5)
+	value, proof := aVRF.Eval(x)
+
+	verifier, _ := NewVRF("BMR10")
+	if err := verifier.SetParams(params, generator, lIn, lCode); err != nil {
+		t.Fatal(err)
+	}
+	verifier.SetPubKey(aVRF.GetPubKey())
+	if !verifier.Verify(x, value, proof) {
+		t.Error("output does not verify with SetParams")
+	}
+
+	bad := []struct {
+		name      string
+		params    string
+		generator []byte
+		lIn       int
+		lCode     int
+	}{
+		{"garbage params", "type x", generator, lIn, lCode},
+		{"lCode", params, generator, lIn, lCode - 1},
+		{"negative lIn", params, generator, -1, 0},
+		{"short generator", params, generator[:len(generator)-1], lIn, lCode},
+		{"no key group generator", params, generator[:len(generator)/3], lIn, lCode},
+	}
+	for _, tc := range bad {
+		verifier, _ := NewVRF("BMR10")
+		if err := verifier.SetParams(tc.params, tc.generator, tc.lIn, tc.lCode); err == nil {
+			t.Errorf("%s accepted", tc.name)
+		}
+		if verifier.params != nil || verifier.g != nil {
+			t.Errorf("%s: failed SetParams changed the VRF", tc.name)
+		}
+	}
+	if _, ok := LookupBackend("pbc"); ok {
+		weak, _ := PresetParams("d159")
+		if err := verifier.SetParams(weak.String(), nil, 0, 0); err != ErrWeakParams {
+			t.Errorf("d159 params: %v", err)
+		}
+	}
+}
+
+func TestSharedParams(t *testing.T) {
+	backend, _ := LookupBackend("bn256")
+	params, err := NewParams(80, WithBackend(backend))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := big.NewInt(9)
+	for _, typeVRF := range schemes {
+		var keys []*abstractVRF
+		for range 2 {
+			aVRF, _ := NewVRF(typeVRF)
+			aVRF.GenKey(params)
+			if aVRF.Params().Fingerprint() != params.Fingerprint() {
+				t.Errorf("%s: GenKey did not use the shared params", typeVRF)
+			}
+			keys = append(keys, aVRF)
+		}
+		value, proof := keys[0].Eval(x)
+		for i, tc := range []struct {
+			pubKey []Element
+			valid  bool
+		}{
+			{keys[0].GetPubKey(), true},
+			{keys[1].GetPubKey(), false},
+		} {
+			verifier, _ := NewVRF(typeVRF)
+			verifier.UseParams(params)
+			if err := verifier.SetPubKey(tc.pubKey); err != nil {
+				t.Fatal(err)
+			}
+			if verifier.Verify(x, value, proof) != tc.valid {
+				t.Errorf("%s: output verifies under key %d: %v", typeVRF, i, !tc.valid)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package vrf
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+)
+
+// ****** Proof of Possession ******
+// ProvePossession proves that the holder of the public key knows its secret
+// key (the key proof of keyproof.go, hashed with a context). The context
+// binds the proof to its use, e.g. the protocol session and the identity of
+// the holder, so that a copied key and proof cannot be registered by
+// someone else. KeyRegistry only hands out verifiers for keys registered
+// with a valid proof.
+
+const possessionDST = "VRF-V01-POP_XMD:SHA-256_RO_"
+
+var (
+	ErrInvalidPossession = errors.New("vrf: invalid proof of possession")
+	ErrKeyRegistered     = errors.New("vrf: key id already registered")
+	ErrUnknownKey        = errors.New("vrf: key id not registered")
+)
+
+// ProvePossession proves possession of the secret key for context.
+func (aVRF *abstractVRF) ProvePossession(context string) ([]Element, error) {
+	var pop []Element
+	var err error
+	if lockErr := aVRF.withSecKey(func() {
+		pop, err = aVRF.proveKey(nil, possessionDST, []byte(context))
+	}); lockErr != nil {
+		return nil, lockErr
+	}
+	return pop, err
+}
+
+// VerifyPossession checks pop, made by ProvePossession for context, for the
+// public key pk.
+func (aVRF *abstractVRF) VerifyPossession(pk []Element, pop []Element, context string) bool {
+	return aVRF.verifyKey(pk, pop, possessionDST, []byte(context))
+}
+
+// PossessionContext is the context of the proof of possession that
+// KeyRegistry expects for id: the length prefixed domain, then id.
+func PossessionContext(domain, id string) string {
+	return string(binary.BigEndian.AppendUint32(nil, uint32(len(domain)))) + domain + id
+}
+
+// KeyRegistry holds the public keys of one scheme and params, each
+// registered under an id with a proof of possession for
+// PossessionContext(domain, id). The keys are made by NewVRF with opts,
+// e.g. WithCode or WithInputBits.
+type KeyRegistry struct {
+	typeVRF string
+	opts    []Option
+	params  *Params
+	domain  string
+
+	mu   sync.RWMutex
+	keys map[string]*abstractVRF
+}
+
+func NewKeyRegistry(typeVRF string, params *Params, domain string, opts ...Option) *KeyRegistry {
+	return &KeyRegistry{
+		typeVRF: typeVRF,
+		opts:    opts,
+		params:  params,
+		domain:  domain,
+		keys:    map[string]*abstractVRF{},
+	}
+}
+
+// Register adds pk under id once pop is checked.
+func (reg *KeyRegistry) Register(id string, pk []Element, pop []Element) error {
+	verifier, err := NewVRF(reg.typeVRF, reg.opts...)
+	if err != nil {
+		return err
+	}
+	verifier.UseParams(reg.params)
+	if !verifier.VerifyPossession(pk, pop, PossessionContext(reg.domain, id)) ||
+		verifier.SetPubKey(pk) != nil {
+		return ErrInvalidPossession
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.keys[id]; ok {
+		return ErrKeyRegistered
+	}
+	reg.keys[id] = verifier
+	return nil
+}
+
+// Verifier returns a VRF holding the public key of id, for Verify.
+func (reg *KeyRegistry) Verifier(id string) (*abstractVRF, error) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	verifier, ok := reg.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return verifier, nil
+}
+
+// PublicKey returns the public key registered under id.
+func (reg *KeyRegistry) PublicKey(id string) ([]Element, error) {
+	verifier, err := reg.Verifier(id)
+	if err != nil {
+		return nil, err
+	}
+	return verifier.GetPubKey(), nil
+}
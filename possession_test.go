@@ -0,0 +1,48 @@
+package vrf
+
+import "testing"
+
+func TestRegisterPossession(t *testing.T) {
+	for _, tc := range []struct {
+		typeVRF string
+		opts    []Option
+	}{
+		{"DY05", nil},
+		{"BMR10", nil},
+		{"BMR10", []Option{WithInputBits(32)}},
+	} {
+		aVRF := newTestVRF(t, tc.typeVRF, "bn256", tc.opts...)
+		pk := aVRF.GetPubKey()
+		pop, err := aVRF.ProvePossession(PossessionContext("test", "alice"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reg := NewKeyRegistry(tc.typeVRF, aVRF.Params(), "test", tc.opts...)
+		for _, c := range []struct {
+			id   string
+			pop  []Element
+			want error
+		}{
+			{"bob", pop, ErrInvalidPossession},
+			{"alice", pop[:len(pop)-1], ErrInvalidPossession},
+			{"alice", pop, nil},
+			{"alice", pop, ErrKeyRegistered},
+		} {
+			if err := reg.Register(c.id, pk, c.pop); err != c.want {
+				t.Errorf("%s %d: Register(%s): %v, want %v", tc.typeVRF, len(tc.opts), c.id, err, c.want)
+			}
+		}
+		if _, err := reg.Verifier("bob"); err != ErrUnknownKey {
+			t.Errorf("%s: Verifier(bob): %v", tc.typeVRF, err)
+		}
+		if got, err := reg.PublicKey("alice"); err != nil || !got[0].Equals(pk[0]) {
+			t.Errorf("%s: PublicKey(alice): %v", tc.typeVRF, err)
+		}
+
+		other := NewKeyRegistry(tc.typeVRF, aVRF.Params(), "other", tc.opts...)
+		if err := other.Register("alice", pk, pop); err != ErrInvalidPossession {
+			t.Errorf("%s: proof for another domain: %v", tc.typeVRF, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package vrf
+
+import (
+	"bufio"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ****** Security Levels ******
+// lambda, as passed to Gen and NewParams, is the target security level in
+// bits (80, 112, 128, 192 or 256), not a group size. It is turned into a
+// SecurityPolicy: the minimum sizes of
+// * r: the group order (Pollard rho on the curve)
+// * q: the base field
+// * q^k: the field GT lives in (NFS on the embedding field), where k is the
+//   embedding degree. The NIST SP 800-57 finite field sizes are used for
+//   k <= 2 and the tower-NFS adjusted sizes of Barbulescu-Duquesne for
+//   larger k.
+// The curve family and its sizes are then picked to meet the policy, and
+// imported parameter sets are refused when they fall below it.
+
+// SecurityPolicy is the minimum size of a parameter set.
+type SecurityPolicy struct {
+	MinRBits        int // group order
+	MinQBits        int // base field
+	MinFieldBits    int // q^k for k <= 2
+	MinTowerNFSBits int // q^k for k > 2
+}
+
+// ParamsInfo describes the sizes of a parameter set.
+type ParamsInfo struct {
+	Type            string
+	RBits           int
+	QBits           int
+	EmbeddingDegree int
+}
+
+var (
+	securityPolicies = map[uint32]SecurityPolicy{
+		80:  {160, 160, 1024, 2048},
+		112: {224, 224, 2048, 4200},
+		128: {256, 256, 3072, 5400},
+		192: {384, 384, 7680, 12000},
+		256: {512, 512, 15360, 24000},
+	}
+
+	// ImportPolicy is checked by ParseParams and UnMarshalParams, where the
+	// intended security level is not known.
+	ImportPolicy = securityPolicies[80]
+
+	ErrSecurityLevel = errors.New("vrf: unsupported security level")
+	ErrWeakParams    = errors.New("vrf: params below security policy")
+)
+
+// PolicyFor returns the policy of a security level in bits.
+func PolicyFor(level uint32) (SecurityPolicy, error) {
+	policy, ok := securityPolicies[level]
+	if !ok {
+		return SecurityPolicy{}, ErrSecurityLevel
+	}
+	return policy, nil
+}
+
+// Sizes returns the group order and base field bits a curve family needs to
+// meet the policy.
+func (policy SecurityPolicy) Sizes(curve Curve) (rbits uint32, qbits uint32) {
+	r := policy.MinRBits
+	switch curve {
+	case CurveA:
+		return uint32(r), uint32(max(policy.MinQBits, policy.MinFieldBits/2))
+	case CurveA1:
+		// composite order: n has to resist factoring as well
+		return uint32(policy.MinFieldBits), uint32(policy.MinFieldBits)
+	case CurveE:
+		return uint32(r), uint32(max(policy.MinQBits, policy.MinFieldBits))
+	case CurveD:
+		n := uint32(max(r, policy.MinTowerNFSBits/6))
+		return n, n
+	case CurveF:
+		n := uint32(max(r, policy.MinTowerNFSBits/12))
+		return n, n
+	case CurveG:
+		n := uint32(max(r, policy.MinTowerNFSBits/10))
+		return n, n
+	}
+	return uint32(r), uint32(policy.MinQBits)
+}
+
+// Check reports ErrWeakParams when info falls below the policy.
+func (policy SecurityPolicy) Check(info ParamsInfo) error {
+	minField := policy.MinFieldBits
+	if info.EmbeddingDegree > 2 {
+		minField = policy.MinTowerNFSBits
+	}
+	if info.RBits < policy.MinRBits || info.QBits < policy.MinQBits ||
+		info.QBits*info.EmbeddingDegree < minField {
+		return ErrWeakParams
+	}
+	return nil
+}
+
+// securityLevel is the highest level whose policy info meets, 0 when it
+// meets none.
+func securityLevel(info ParamsInfo) uint32 {
+	var level uint32
+	for l, policy := range securityPolicies {
+		if l > level && policy.Check(info) == nil {
+			level = l
+		}
+	}
+	return level
+}
+
+// CheckParams checks params against policy.
+func CheckParams(params PairingParams, policy SecurityPolicy) error {
+	info, err := ParamsInfoOf(params)
+	if err != nil {
+		return err
+	}
+	return policy.Check(info)
+}
+
+// ParamsInfoOf reads the sizes from the text encoding of params, which is
+// the PBC .param format for every backend.
+func ParamsInfoOf(params PairingParams) (ParamsInfo, error) {
+	fields := paramFields(params)
+	bits := func(key string) int {
+		n, ok := new(big.Int).SetString(fields[key], 10)
+		if !ok {
+			return 0
+		}
+		return n.BitLen()
+	}
+
+	info := ParamsInfo{Type: fields["type"]}
+	switch info.Type {
+	case "a":
+		info.RBits, info.QBits, info.EmbeddingDegree = bits("r"), bits("q"), 2
+	case "a1":
+		info.RBits, info.QBits, info.EmbeddingDegree = bits("n"), bits("p"), 2
+	case "d":
+		info.RBits, info.QBits = bits("r"), bits("q")
+		info.EmbeddingDegree, _ = strconv.Atoi(fields["k"])
+	case "e":
+		info.RBits, info.QBits, info.EmbeddingDegree = bits("r"), bits("q"), 1
+	case "f":
+		info.RBits, info.QBits, info.EmbeddingDegree = bits("r"), bits("q"), 12
+	case "g":
+		info.RBits, info.QBits, info.EmbeddingDegree = bits("r"), bits("q"), 10
+	case "bn256":
+		info.RBits, info.QBits, info.EmbeddingDegree = 256, 256, 12
+	default:
+		return info, ErrUnknownParams
+	}
+	if info.RBits == 0 || info.QBits == 0 || info.EmbeddingDegree == 0 {
+		return info, ErrUnknownParams
+	}
+	return info, nil
+}
+
+// paramFields splits the "key value" lines of the text encoding of params.
+func paramFields(params PairingParams) map[string]string {
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(params.String()))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		fields[key] = strings.TrimSpace(value)
+	}
+	return fields
+}
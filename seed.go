@@ -0,0 +1,97 @@
+package vrf
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/big"
+)
+
+// ****** Deterministic Generation ******
+// By default every random value comes from the backend (Element.Rand). With
+// WithRand they are read from an io.Reader instead, and with WithSeed from a
+// SHA-256 expander over a 32-byte seed, so the same seed always gives the
+// same generators and keys:
+//		block[i] = SHA256(len(dst) || dst || seed || i)
+// The expander is keyed with a different dst for the params and for the key
+// of each scheme. Pairing parameters themselves are only reproducible when
+// they are fixed (WithParams, presets, or the bn256 backend).
+
+const (
+	seedParamsDST = "VRF-PARAMS-V1"
+	seedKeyDST    = "VRF-KEY-V1-"
+)
+
+// WithRand draws the generators and key elements from r.
+func WithRand(r io.Reader) GenOption {
+	return func(o *genOptions) {
+		o.rand = r
+	}
+}
+
+// WithSeed derives the generators and key elements from seed.
+func WithSeed(seed [32]byte) GenOption {
+	return func(o *genOptions) {
+		o.seed = &seed
+	}
+}
+
+// reader returns the randomness for dst, nil for the backend's own.
+func (o *genOptions) reader(dst string) io.Reader {
+	if o.seed != nil {
+		return &seedReader{seed: o.seed[:], dst: []byte(dst)}
+	}
+	return o.rand
+}
+
+type seedReader struct {
+	seed    []byte
+	dst     []byte
+	counter uint64
+	buf     []byte
+}
+
+func (r *seedReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			h := sha256.New()
+			h.Write([]byte{byte(len(r.dst))})
+			h.Write(r.dst)
+			h.Write(r.seed)
+			binary.Write(h, binary.BigEndian, r.counter)
+			r.buf = h.Sum(nil)
+			r.counter++
+		}
+		c := copy(p[n:], r.buf)
+		r.buf = r.buf[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// randZr returns a random element of Zr read from r, with 128 extra bits so
+// the reduction mod r is unbiased.
+func randZr(pairing Pairing, r io.Reader) (Element, error) {
+	el := pairing.NewZr()
+	if r == nil {
+		return el.Rand(), nil
+	}
+	buf := make([]byte, len(el.Bytes())+16)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return el.SetBig(new(big.Int).SetBytes(buf)), nil
+}
+
+// randElement sets el to a random element of its group read from r.
+func randElement(el Element, r io.Reader) (Element, error) {
+	if r == nil {
+		return el.Rand(), nil
+	}
+	buf := make([]byte, len(el.Bytes())+16)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return el.SetFromHash(buf), nil
+}
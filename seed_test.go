@@ -0,0 +1,45 @@
+package vrf
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestSeededGen(t *testing.T) {
+	stream := bytes.Repeat([]byte("0123456789abcdef"), 1<<12)
+	for _, typeVRF := range schemes {
+		for _, tc := range []struct {
+			name       string
+			a, b, diff Option
+		}{
+			{"seed", WithSeed([32]byte{1}), WithSeed([32]byte{1}), WithSeed([32]byte{2})},
+			{"reader", WithRand(bytes.NewReader(stream)), WithRand(bytes.NewReader(stream)), WithRand(bytes.NewReader(stream[1:]))},
+		} {
+			a := newTestVRF(t, typeVRF, "bn256", tc.a)
+			b := newTestVRF(t, typeVRF, "bn256", tc.b)
+			diff := newTestVRF(t, typeVRF, "bn256", tc.diff)
+			if !slices.Equal(a.MarshalParams(), b.MarshalParams()) || !slices.Equal(a.MarshalSecKey(), b.MarshalSecKey()) {
+				t.Errorf("%s, %s: same randomness gives different keys", typeVRF, tc.name)
+			}
+			if slices.Equal(a.MarshalSecKey(), diff.MarshalSecKey()) || slices.Equal(a.MarshalPubKey(), diff.MarshalPubKey()) {
+				t.Errorf("%s, %s: other randomness gives the same key", typeVRF, tc.name)
+			}
+		}
+	}
+}
+
+func TestSeededGenKey(t *testing.T) {
+	shared := newTestVRF(t, "DY05", "bn256").Params()
+	for _, typeVRF := range schemes {
+		var keys [][]string
+		for _, seed := range [][32]byte{{1}, {1}, {2}} {
+			aVRF, _ := NewVRF(typeVRF)
+			aVRF.GenKey(shared, WithSeed(seed))
+			keys = append(keys, aVRF.MarshalPubKey())
+		}
+		if !slices.Equal(keys[0], keys[1]) || slices.Equal(keys[0], keys[2]) {
+			t.Errorf("%s: keys on shared params do not follow the seed", typeVRF)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package vrf
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ****** Detailed Verification ******
+// VerifyDetailed runs the same checks as Verify and reports the first one
+// that failed:
+// * CheckMalformed: the proof has the wrong length or does not decode
+// * CheckInput: the input policy refused x
+// * CheckStart: the chain does not start at g (BMR10/DOD03)
+// * CheckKey: e(proof, gk^x pk) != e(g, gk) (DY05)
+// * CheckChain: chain step Index, from 1, does not hold (BMR10/DOD03)
+// * CheckOutput: the value is not bound to the proof
+// With WithTrace every pairing equation is recorded with both sides and the
+// intermediate values it was computed from, for a chain step the code bit,
+// v[i-1], v[i] and the key element.
+
+var (
+	ErrProofLength = errors.New("vrf: proof has the wrong length")
+	ErrKeyLength   = errors.New("vrf: public key has the wrong length")
+)
+
+// CheckKind names a check of VerifyDetailed.
+type CheckKind int
+
+const (
+	CheckNone CheckKind = iota
+	CheckMalformed
+	CheckInput
+	CheckStart
+	CheckKey
+	CheckChain
+	CheckOutput
+)
+
+func (kind CheckKind) String() string {
+	switch kind {
+	case CheckNone:
+		return "none"
+	case CheckMalformed:
+		return "malformed"
+	case CheckInput:
+		return "input"
+	case CheckStart:
+		return "start"
+	case CheckKey:
+		return "key"
+	case CheckChain:
+		return "chain"
+	case CheckOutput:
+		return "output"
+	}
+	return "unknown"
+}
+
+// VerifyResult is the outcome of VerifyDetailed.
+type VerifyResult struct {
+	Scheme   string
+	Valid    bool
+	Kind     CheckKind // failed check, CheckNone when valid
+	Index    int       // failed chain step for CheckChain
+	Err      error     // cause for CheckMalformed and CheckInput
+	Input    *big.Int  // x after the input policy
+	Encoding string    // codeword of x for BMR10/DOD03
+	Trace    []TraceEntry
+
+	trace bool
+	terms []TraceTerm // recorded for the next check
+}
+
+// TraceEntry is one equation checked by VerifyDetailed with WithTrace.
+type TraceEntry struct {
+	Kind     CheckKind
+	Index    int
+	LHS, RHS string
+	Terms    []TraceTerm
+}
+
+// TraceTerm is a named intermediate value of an equation.
+type TraceTerm struct {
+	Name, Value string
+}
+
+func (res *VerifyResult) String() string {
+	if res.Valid {
+		return fmt.Sprintf("%s: valid", res.Scheme)
+	}
+	switch res.Kind {
+	case CheckChain:
+		return fmt.Sprintf("%s: invalid, chain step %d", res.Scheme, res.Index)
+	case CheckMalformed, CheckInput:
+		return fmt.Sprintf("%s: invalid, %v: %v", res.Scheme, res.Kind, res.Err)
+	}
+	return fmt.Sprintf("%s: invalid, %v", res.Scheme, res.Kind)
+}
+
+type verifyOptions struct {
+	trace bool
+}
+
+type VerifyOption func(*verifyOptions)
+
+// WithTrace records both sides of every equation in VerifyResult.Trace.
+func WithTrace() VerifyOption {
+	return func(o *verifyOptions) {
+		o.trace = true
+	}
+}
+
+// term records an intermediate value of the next checked equation.
+func (res *VerifyResult) term(name string, value any) {
+	if res.trace {
+		res.terms = append(res.terms, TraceTerm{name, fmt.Sprint(value)})
+	}
+}
+
+// check compares the two sides of an equation, and records it as the failed
+// check when they differ.
+func (res *VerifyResult) check(kind CheckKind, index int, lhs, rhs Element) bool {
+	if res.trace {
+		res.Trace = append(res.Trace, TraceEntry{kind, index, lhs.String(), rhs.String(), res.terms})
+		res.terms = nil
+	}
+	if lhs.Equals(rhs) {
+		return true
+	}
+	res.Kind, res.Index = kind, index
+	return false
+}
+
+// fail records a check failed for cause.
+func (res *VerifyResult) fail(kind CheckKind, err error) bool {
+	res.Kind, res.Err = kind, err
+	return false
+}
+
+func newVerifyResult(scheme string, opts []VerifyOption) *VerifyResult {
+	o := &verifyOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &VerifyResult{Scheme: scheme, trace: o.trace}
+}
+
+// VerifyDetailed verifies like Verify and explains the result. Elements that
+// do not decode make the proof malformed. A VRF without a public key of its
+// scheme is a bug of the caller and panics.
+func (aVRF *abstractVRF) VerifyDetailed(x *big.Int, y Element, proof []Element, opts ...VerifyOption) (res *VerifyResult) {
+	if aVRF.typeVRF == "" {
+		panic("..")
+	}
+	if len(aVRF.pubKey) != aVRF.pubKeyLength() {
+		panic("vrf: Verify without a public key")
+	}
+	res = newVerifyResult(aVRF.typeVRF, opts)
+
+	in, err := aVRF.mapInput(x)
+	if err != nil {
+		res.fail(CheckInput, err)
+		return res
+	}
+	res.Input = in
+	switch aVRF.typeVRF {
+	case "DY05":
+		res.Valid = aVRF.DY05VerifyDetailed(in, y, proof, res)
+	case "BMR10":
+		res.Valid = aVRF.BMR10VerifyDetailed(in, y, proof, res)
+	case "DOD03":
+		res.Valid = aVRF.DOD03VerifyDetailed(in, y, proof, res)
+	default:
+		res.Valid = aVRF.DY05VerifyDetailed(in, y, proof, res)
+	}
+	return res
+}
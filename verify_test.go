@@ -0,0 +1,82 @@
+package vrf
+
+import (
+	"math/big"
+	"testing"
+)
+
+// verifyCase is an output handed to VerifyDetailed and the check it fails.
+type verifyCase struct {
+	name  string
+	x     *big.Int
+	value Element
+	proof []Element
+	kind  CheckKind
+	index int
+}
+
+func TestVerifyDetailed(t *testing.T) {
+	x := big.NewInt(21)
+	for _, typeVRF := range []string{"DY05", "BMR10"} {
+		aVRF := newTestVRF(t, typeVRF, "bn256")
+		value, proof := aVRF.Eval(x)
+		otherValue, otherProof := aVRF.Eval(big.NewInt(22))
+		replace := func(i int, el Element) []Element {
+			changed := append([]Element{}, proof...)
+			changed[i] = el
+			return changed
+		}
+		g1 := aVRF.pairing.NewG1().SetFromHash([]byte("other"))
+
+		cases := []verifyCase{
+			{"valid", x, value, proof, CheckNone, 0},
+			{"short proof", x, value, proof[1:], CheckMalformed, 0},
+			{"nil element", x, value, replace(0, nil), CheckMalformed, 0},
+			{"element of Zr", x, value, replace(0, aVRF.pairing.NewZr().SetInt32(3)), CheckMalformed, 0},
+			{"nil value", x, nil, proof, CheckMalformed, 0},
+			{"negative input", big.NewInt(-1), value, proof, CheckInput, 0},
+			{"other value", x, otherValue, proof, CheckOutput, 0},
+		}
+		switch typeVRF {
+		case "DY05":
+			cases = append(cases, verifyCase{"other output", x, otherValue, otherProof, CheckKey, 0})
+		case "BMR10":
+			cases = append(cases,
+				verifyCase{"input beyond lIn bits", new(big.Int).Lsh(big.NewInt(1), 64), value, proof, CheckInput, 0},
+				verifyCase{"start", x, value, replace(0, g1), CheckStart, 0},
+				verifyCase{"chain", x, value, replace(3, g1), CheckChain, 3},
+				verifyCase{"other output", x, otherValue, otherProof, CheckChain, 1},
+			)
+		}
+		for _, tc := range cases {
+			res := aVRF.VerifyDetailed(tc.x, tc.value, tc.proof)
+			if res.Valid != (tc.kind == CheckNone) || res.Kind != tc.kind || res.Index != tc.index {
+				t.Errorf("%s, %s: valid %v, %v at %d (%v), want %v at %d", typeVRF, tc.name, res.Valid, res.Kind, res.Index, res.Err, tc.kind, tc.index)
+			}
+		}
+	}
+}
+
+func TestVerifyWithoutPubKey(t *testing.T) {
+	aVRF := newTestVRF(t, "DY05", "bn256")
+	value, proof := aVRF.Eval(big.NewInt(1))
+	verifier, _ := NewVRF("DY05")
+	verifier.UseParams(aVRF.Params())
+	defer func() {
+		if recover() == nil {
+			t.Error("Verify without a public key did not panic")
+		}
+	}()
+	verifier.Verify(big.NewInt(1), value, proof)
+}
+
+func TestSetPubKeyLength(t *testing.T) {
+	aVRF := newTestVRF(t, "BMR10", "bn256")
+	pubKey := aVRF.GetPubKey()
+	if err := aVRF.SetPubKey(pubKey[1:]); err != ErrKeyLength {
+		t.Errorf("short public key: %v", err)
+	}
+	if len(aVRF.pubKey) != len(pubKey) {
+		t.Error("failed SetPubKey changed the public key")
+	}
+}
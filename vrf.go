@@ -3,115 +3,194 @@ package vrf
 import (
 	//"fmt"
 
+	"context"
 	"math/big"
-
-	"github.com/Nik-U/pbc"
+	"strconv"
+	"sync"
 )
 
 type VRF interface {
 	//	******`*************** Main Functions *********************
-	Gen(lambda int)
-	Eval(x *big.Int) (*pbc.Element, []*pbc.Element)
-	Verify(x *big.Int, y *pbc.Element, proof []*pbc.Element) bool
+	Gen(lambda uint32, opts ...GenOption) error
+	GenContext(ctx context.Context, lambda uint32, opts ...GenOption) error
+	Eval(x *big.Int, opts ...EvalOption) (Element, []Element)
+	TryEval(x *big.Int, opts ...EvalOption) (Element, []Element, error)
+	Verify(x *big.Int, y Element, proof []Element) bool
 	//	********************* Main Functions *********************
 
 	//	********************* Import/Export **********************
-	SetPubKey() []*pbc.Element
+	SetPubKey() []Element
 	MarshalPubKey() []string
-	SetSecKey() []*pbc.Element
+	SetSecKey() []Element
 	MarshalSecKey() []string
-	SetParams() (string, *pbc.Element, int, int)
+	SetParams() (string, Element, int, int)
 	MarshalParams() []string
 
-	GetPubKey([]*pbc.Element)
+	GetPubKey([]Element)
 	UnMarshalPubKey([]string) error
-	GetSecKey([]*pbc.Element)
+	GetSecKey([]Element)
 	UnMarshalSecKey([]string) error
-	GetParams(*pbc.Params, *pbc.Element, int, int)
+	GetParams(PairingParams, Element, int, int)
 	UnMarshalParams([]string) error
 	//	********************* Import/Export **********************
 }
 
-type abstractVRF struct {
-	pubKey  []*pbc.Element
-	secKey  []*pbc.Element
-	params  *pbc.Params
-	pairing *pbc.Pairing
-	g       *pbc.Element
-	lIn     int
-	lCode   int
-	typeVRF string
-}
-
-func NewVRF(typeVRF ...string) *abstractVRF {
-	if len(typeVRF) > 1 {
-		panic("...")
+// Placement says which source group of the pairing holds the public keys.
+// Proofs live in the other one. It only matters for asymmetric (Type-3)
+// pairings, where G1 != G2.
+type Placement int
+
+const (
+	PubKeyInG2 Placement = iota // pk in G2, proofs in G1
+	PubKeyInG1                  // pk in G1, proofs in G2
+)
+
+func (p Placement) String() string {
+	if p == PubKeyInG1 {
+		return "G1"
 	}
+	return "G2"
+}
 
-	var mode string
-	if len(typeVRF) == 1 {
-		mode = typeVRF[0]
-	} else {
-		mode = "DY05"
+func ParsePlacement(s string) (Placement, bool) {
+	switch s {
+	case "G2", "":
+		return PubKeyInG2, true
+	case "G1":
+		return PubKeyInG1, true
 	}
-	return &abstractVRF{typeVRF: mode}
+	return PubKeyInG2, false
 }
 
-func (aVRF *abstractVRF) Gen(lambda uint32) {
-	if aVRF.typeVRF == "" {
-		panic("...")
+type abstractVRF struct {
+	pubKey    []Element
+	secKey    []Element
+	backend   Backend
+	params    PairingParams
+	pairing   Pairing
+	placement Placement
+	g         Element // generator of the proof group
+	gk        Element // generator of the key group, g when symmetric
+	seed      string  // public seed of g, gk and h, "" when random
+	h         Element // h of BMR10/DOD03 derived from seed, nil when random
+	keyProof  []Element
+	lockedKey *lockedBuffer // secret exponents after LockSecKey
+	keyMu     sync.Mutex    // held while secKey is decoded from lockedKey
+	input     *InputPolicy  // input domain, nil for the scheme default
+	opts      []Option      // of NewVRF, defaults for Gen
+	lIn       int
+	lCode     int
+	typeVRF   string
+}
+
+// SetBackend selects the group backend used by Gen. Params imported with
+// SetParams or UnMarshalParams carry their own backend.
+func (aVRF *abstractVRF) SetBackend(backend Backend) {
+	aVRF.backend = backend
+}
+
+func (aVRF *abstractVRF) Backend() Backend {
+	if aVRF.backend == nil {
+		return DefaultBackend()
 	}
-	switch aVRF.typeVRF {
-	case "DY05":
-		aVRF.DY05Gen(lambda)
-	case "BMR10":
-		aVRF.BMR10Gen(lambda)
-	case "DOD03":
-		aVRF.DOD03Gen(lambda)
-	default:
-		aVRF.DY05Gen(lambda)
+	return aVRF.backend
+}
+
+// SetPlacement chooses the groups of keys and proofs for asymmetric
+// pairings. It must be called before Gen or SetParams.
+func (aVRF *abstractVRF) SetPlacement(placement Placement) {
+	aVRF.placement = placement
+}
+
+// Gen generates params for security level lambda and a key on them, see
+// GenContext. It returns ErrWeakParams when the backend does not reach lambda,
+// e.g. bn256 above level 80.
+func (aVRF *abstractVRF) Gen(lambda uint32, opts ...GenOption) error {
+	return aVRF.GenContext(context.Background(), lambda, opts...)
+}
+
+// Eval is TryEval, panicking on error.
+func (aVRF *abstractVRF) Eval(x *big.Int, opts ...EvalOption) (Element, []Element) {
+	value, proof, err := aVRF.TryEval(x, opts...)
+	if err != nil {
+		panic(err)
 	}
+	return value, proof
 }
 
-func (aVRF *abstractVRF) Eval(x *big.Int) (*pbc.Element, []*pbc.Element) {
+// TryEval evaluates the VRF on x. It returns ErrInputDomain for inputs
+// refused by the input policy, ErrDestroyed without a secret key and, with
+// WithSelfCheck, ErrSelfCheck, and no output, when the output does not
+// verify.
+func (aVRF *abstractVRF) TryEval(x *big.Int, opts ...EvalOption) (Element, []Element, error) {
 	if aVRF.typeVRF == "" {
 		panic("...")
 	}
-	var value *pbc.Element
-	var proof []*pbc.Element
+	var value Element
+	var proof []Element
 
-	switch aVRF.typeVRF {
-	case "DY05":
-		value, proof = aVRF.DY05Eval(x)
-	case "BMR10":
-		value, proof = aVRF.BMR10Eval(x)
-	case "DOD03":
-		value, proof = aVRF.DOD03Eval(x)
-	default:
-		value, proof = aVRF.DY05Eval(x)
+	in, err := aVRF.mapInput(x)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = aVRF.withSecKey(func() {
+		switch aVRF.typeVRF {
+		case "DY05":
+			value, proof = aVRF.DY05Eval(in, opts...)
+		case "BMR10":
+			value, proof = aVRF.BMR10Eval(in, opts...)
+		case "DOD03":
+			value, proof = aVRF.DOD03Eval(in, opts...)
+		default:
+			value, proof = aVRF.DY05Eval(in, opts...)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return value, proof
+	if newEvalOptions(opts).selfCheck && !aVRF.Verify(x, value, proof) {
+		return nil, nil, ErrSelfCheck
+	}
+	return value, proof, nil
 }
 
-func (aVRF *abstractVRF) Verify(x *big.Int, y *pbc.Element, proof []*pbc.Element) bool {
+func (aVRF *abstractVRF) Verify(x *big.Int, y Element, proof []Element) bool {
 	if aVRF.typeVRF == "" {
 		panic("..")
 	}
 
-	var checkBit bool
+	return aVRF.VerifyDetailed(x, y, proof).Valid
+}
+
+// mapInput applies the input policy of the scheme to x.
+func (aVRF *abstractVRF) mapInput(x *big.Int) (*big.Int, error) {
+	switch aVRF.typeVRF {
+	case "BMR10", "DOD03":
+		// inputs beyond lIn bits cannot be encoded, refuse them by default
+		policy := aVRF.input
+		if policy == nil {
+			policy = &InputPolicy{}
+		}
+		return policy.apply(x, aVRF.lIn, CodeInputDST)
+	default:
+		return aVRF.DY05Input(x)
+	}
+}
+
+// DeriveChild derives the child key at path, see DY05DeriveChild. Only DY05
+// keys have children. Child keys are not independent: a child secret key
+// and the parent public key give the parent secret key, and the child output
+// on x is the parent output on an input shifted by a public t. The child
+// therefore hashes its inputs (InputPolicy.Hash); the parent should hash
+// too, or an output of the parent can pass for one of the child.
+func (aVRF *abstractVRF) DeriveChild(path string) (*abstractVRF, error) {
 	switch aVRF.typeVRF {
 	case "DY05":
-		checkBit = aVRF.DY05Verify(x, y, proof)
-	case "BMR10":
-		checkBit = aVRF.BMR10Verify(x, y, proof)
-	case "DOD03":
-		checkBit = aVRF.DOD03Verify(x, y, proof)
+		return aVRF.DY05DeriveChild(path)
 	default:
-		checkBit = aVRF.DY05Verify(x, y, proof)
+		return nil, ErrDeriveScheme
 	}
-
-	return checkBit
 }
 
 func (aVRF *abstractVRF) GenNewPubKey() {
@@ -131,52 +210,151 @@ func (aVRF *abstractVRF) GenNewPubKey() {
 	}
 }
 
-func (aVRF *abstractVRF) SetPubKey(pubKey []*pbc.Element) {
-	newPubKey := aVRF.MapArrayToCurve(pubKey)
+// SetPubKey imports pubKey. It returns ErrKeyLength or ErrInvalidElement,
+// and keeps the current public key, when pubKey does not have the length of
+// the scheme or an element is not in the key group.
+func (aVRF *abstractVRF) SetPubKey(pubKey []Element) error {
+	if len(pubKey) != aVRF.pubKeyLength() {
+		return ErrKeyLength
+	}
+	newPubKey, err := tryMapArray(pubKey, aVRF.newKeyElement)
+	if err != nil {
+		return err
+	}
 	aVRF.pubKey = newPubKey
+	return nil
 }
 
-func (aVRF *abstractVRF) UnMarshalPubKey(pubKey []string) {
-	var pubKey1 []*pbc.Element
+func (aVRF *abstractVRF) UnMarshalPubKey(pubKey []string) error {
+	var pubKey1 []Element
 	for i := 0; i < len(pubKey); i++ {
-		element, _ := aVRF.pairing.NewG1().SetString(pubKey[i], 10)
+		element, ok := aVRF.newKeyElement().SetString(pubKey[i], 10)
+		if !ok {
+			return ErrInvalidElement
+		}
 		pubKey1 = append(pubKey1, element)
 	}
-	aVRF.SetPubKey(pubKey1)
+	return aVRF.SetPubKey(pubKey1)
 }
 
-func (aVRF *abstractVRF) SetSecKey(secKey []*pbc.Element) {
-	newSecKey := aVRF.MapArrayToCurveZ(secKey)
+// SetSecKey imports secKey and derives the public key from it. It returns
+// ErrInvalidElement, and keeps the current keys, when an element does not
+// decode.
+func (aVRF *abstractVRF) SetSecKey(secKey []Element) error {
+	var newSecKey []Element
+	var err error
+	if aVRF.typeVRF == "BMR10" || aVRF.typeVRF == "DOD03" {
+		// sk[0] is h
+		if len(secKey) == 0 {
+			return ErrInvalidElement
+		}
+		var u []Element
+		if newSecKey, err = tryMapArray(secKey[:1], aVRF.newKeyElement); err != nil {
+			return err
+		}
+		if u, err = tryMapArray(secKey[1:], aVRF.pairing.NewZr); err != nil {
+			return err
+		}
+		newSecKey = append(newSecKey, u...)
+	} else if newSecKey, err = tryMapArray(secKey, aVRF.pairing.NewZr); err != nil {
+		return err
+	}
+	aVRF.Destroy()
 	aVRF.secKey = newSecKey
 	aVRF.GenNewPubKey()
+	return nil
 }
 
-func (aVRF *abstractVRF) UnMarshalSecKey(secKey []string) {
-	var secKey1 []*pbc.Element
+func (aVRF *abstractVRF) UnMarshalSecKey(secKey []string) error {
+	var secKey1 []Element
 	for i := 0; i < len(secKey); i++ {
-		element, _ := aVRF.pairing.NewZr().SetString(secKey[i], 10)
+		var element Element
+		var ok bool
+		if i == 0 && (aVRF.typeVRF == "BMR10" || aVRF.typeVRF == "DOD03") {
+			element, ok = aVRF.newKeyElement().SetString(secKey[i], 10)
+		} else {
+			element, ok = aVRF.pairing.NewZr().SetString(secKey[i], 10)
+		}
+		if !ok {
+			return ErrInvalidElement
+		}
 		secKey1 = append(secKey1, element)
 	}
-	aVRF.SetSecKey(secKey1)
+	return aVRF.SetSecKey(secKey1)
 }
 
-func (aVRF *abstractVRF) SetParams(params string, generator []byte, lengthInput int, lengthCode int) {
-	aVRF.params, _ = pbc.NewParamsFromString(params)
-	aVRF.pairing = aVRF.params.NewPairing()
-	aVRF.g = aVRF.pairing.NewG1().SetBytes(generator)
+// SetParams takes the generator bytes returned by GetParams: g, followed by
+// the key group generator when the pairing is asymmetric. Like
+// UnMarshalParams it refuses parameter sets below ImportPolicy, generators
+// that do not decode (ErrInvalidParams) and lengths that do not fit the
+// code, and then leaves aVRF unchanged.
+func (aVRF *abstractVRF) SetParams(params string, generator []byte, lengthInput int, lengthCode int) error {
+	pairingParams, err := ParsePairingParams(params)
+	if err != nil {
+		return err
+	}
+	if err := CheckParams(pairingParams, ImportPolicy); err != nil {
+		return err
+	}
+	if lengthInput < 0 || (lengthInput > 0 && lengthCode != codeLength(lengthInput)) || (lengthInput == 0 && lengthCode != 0) {
+		return ErrInvalidParams
+	}
+	pairing := pairingParams.NewPairing()
+	var g, gk Element
+	if pairing.IsSymmetric() {
+		g, err = pairing.NewG1().TrySetBytes(generator)
+		gk = g
+	} else {
+		n := len(newProofElement(pairing, aVRF.placement).Bytes())
+		if len(generator) < n {
+			return ErrInvalidParams
+		}
+		g, err = newProofElement(pairing, aVRF.placement).TrySetBytes(generator[:n])
+		if err == nil {
+			gk, err = newKeyElement(pairing, aVRF.placement).TrySetBytes(generator[n:])
+		}
+	}
+	if err != nil {
+		return ErrInvalidParams
+	}
+
+	aVRF.params, aVRF.pairing = pairingParams, pairing
+	aVRF.g, aVRF.gk = g, gk
+	aVRF.seed, aVRF.h = "", nil
 	aVRF.lIn = lengthInput
 	aVRF.lCode = lengthCode
+	return nil
 }
 
-func (aVRF *abstractVRF) UnMarshalParams(allParams []string) {
-	aVRF.params, _ = pbc.NewParamsFromString(allParams[0])
-	aVRF.pairing = aVRF.params.NewPairing()
-	aVRF.g, _ = aVRF.pairing.NewG1().SetString(allParams[1], 10)
-	aVRF.lIn = 0
-	aVRF.lCode = 0
+// UnMarshalParams reads the output of MarshalParams or Params.Marshal:
+// (params, g, lIn, lCode, gk, placement, seed). Empty lengths fall back to the
+// scheme's own. It returns the error of ParseParams, e.g. ErrWeakParams, or
+// ErrInvalidParams for lengths that do not fit the code, and then leaves aVRF
+// unchanged.
+func (aVRF *abstractVRF) UnMarshalParams(allParams []string) error {
+	params, err := ParseParams(allParams)
+	if err != nil {
+		return err
+	}
+	lengths := len(allParams) > 3 && allParams[2] != ""
+	lIn, lCode := 0, 0
+	if lengths {
+		var errIn, errCode error
+		lIn, errIn = strconv.Atoi(allParams[2])
+		lCode, errCode = strconv.Atoi(allParams[3])
+		if errIn != nil || errCode != nil || lIn < 0 || (lIn > 0 && lCode != codeLength(lIn)) || (lIn == 0 && lCode != 0) {
+			return ErrInvalidParams
+		}
+	}
+	aVRF.UseParams(params)
+	if lengths {
+		aVRF.lIn, aVRF.lCode = lIn, lCode
+	}
+	return nil
 }
-func (aVRF *abstractVRF) GetPubKey() []*pbc.Element {
-	var pubKey []*pbc.Element
+
+func (aVRF *abstractVRF) GetPubKey() []Element {
+	var pubKey []Element
 	for i := 0; i < len(aVRF.pubKey); i++ {
 		pubKey = append(pubKey, aVRF.pubKey[i])
 	}
@@ -191,67 +369,146 @@ func (aVRF *abstractVRF) MarshalPubKey() []string {
 	return pubKey
 }
 
-func (aVRF *abstractVRF) GetSecKey() []*pbc.Element {
-	var secKey []*pbc.Element
-	for i := 0; i < len(aVRF.secKey); i++ {
-		secKey = append(secKey, aVRF.secKey[i])
-	}
+// GetSecKey returns a copy of the secret key.
+func (aVRF *abstractVRF) GetSecKey() []Element {
+	var secKey []Element
+	aVRF.withSecKey(func() {
+		for i := 0; i < len(aVRF.secKey); i++ {
+			if i < aVRF.secretStart() {
+				secKey = append(secKey, aVRF.newKeyElement().Set(aVRF.secKey[i]))
+			} else {
+				secKey = append(secKey, aVRF.pairing.NewZr().Set(aVRF.secKey[i]))
+			}
+		}
+	})
 	return secKey
 }
 
 func (aVRF *abstractVRF) MarshalSecKey() []string {
 	var secKey []string
-	for i := 0; i < len(aVRF.secKey); i++ {
-		secKey = append(secKey, aVRF.secKey[i].String())
-	}
+	aVRF.withSecKey(func() {
+		for i := 0; i < len(aVRF.secKey); i++ {
+			secKey = append(secKey, aVRF.secKey[i].String())
+		}
+	})
 	return secKey
 }
 
 func (aVRF *abstractVRF) GetParams() (string, []byte, int, int) {
 	params := aVRF.params.String()
 	generator := aVRF.g.Bytes()
+	if !aVRF.pairing.IsSymmetric() {
+		generator = append(generator, aVRF.gk.Bytes()...)
+	}
 	lengthInput := aVRF.lIn
 	lengthCode := aVRF.lCode
 	return params, generator, lengthInput, lengthCode
 }
 
 func (aVRF *abstractVRF) MarshalParams() []string {
-	var allParams []string
-	params := aVRF.params.String()
-	allParams = append(allParams, params)
-	generator := aVRF.g.String()
-	allParams = append(allParams, generator)
-	lengthInput := ""
-	allParams = append(allParams, lengthInput)
-	lengthCode := ""
-	allParams = append(allParams, lengthCode)
+	allParams := aVRF.Params().Marshal()
+	allParams[2] = strconv.Itoa(aVRF.lIn)
+	allParams[3] = strconv.Itoa(aVRF.lCode)
 	return allParams
 }
 
-func (aVRF *abstractVRF) MapArrayToCurve(arr []*pbc.Element) []*pbc.Element {
-	var curveArr []*pbc.Element
+func (aVRF *abstractVRF) MapArrayToCurve(arr []Element) []Element {
+	var curveArr []Element
 	for i := 0; i < len(arr); i++ {
 		curveArr = append(curveArr, aVRF.pairing.NewG1().SetBytes(arr[i].Bytes()))
 	}
 	return curveArr
 }
 
-func (aVRF *abstractVRF) MapElementToCurveT(ele *pbc.Element) *pbc.Element {
-	var curveEle *pbc.Element
+func (aVRF *abstractVRF) MapElementToCurveT(ele Element) Element {
+	var curveEle Element
 	curveEle = aVRF.pairing.NewGT().SetBytes(ele.Bytes())
 	return curveEle
 }
 
-func (aVRF *abstractVRF) MapElementToCurve1(ele *pbc.Element) *pbc.Element {
-	var curveEle *pbc.Element
+func (aVRF *abstractVRF) MapElementToCurve1(ele Element) Element {
+	var curveEle Element
 	curveEle = aVRF.pairing.NewG1().SetBytes(ele.Bytes())
 	return curveEle
 }
 
-func (aVRF *abstractVRF) MapArrayToCurveZ(arr []*pbc.Element) []*pbc.Element {
-	var curveArr []*pbc.Element
+func (aVRF *abstractVRF) MapArrayToProofGroup(arr []Element) []Element {
+	var curveArr []Element
+	for i := 0; i < len(arr); i++ {
+		curveArr = append(curveArr, aVRF.MapElementToProofGroup(arr[i]))
+	}
+	return curveArr
+}
+
+func (aVRF *abstractVRF) MapArrayToKeyGroup(arr []Element) []Element {
+	var curveArr []Element
+	for i := 0; i < len(arr); i++ {
+		curveArr = append(curveArr, aVRF.newKeyElement().SetBytes(arr[i].Bytes()))
+	}
+	return curveArr
+}
+
+func (aVRF *abstractVRF) MapElementToProofGroup(ele Element) Element {
+	return aVRF.newProofElement().SetBytes(ele.Bytes())
+}
+
+func (aVRF *abstractVRF) MapArrayToCurveZ(arr []Element) []Element {
+	var curveArr []Element
 	for i := 0; i < len(arr); i++ {
 		curveArr = append(curveArr, aVRF.pairing.NewZr().SetBytes(arr[i].Bytes()))
 	}
 	return curveArr
 }
+
+// tryMap decodes ele into to, see Element.TrySetBytes.
+func tryMap(ele Element, to Element) (Element, error) {
+	if ele == nil {
+		return nil, ErrInvalidElement
+	}
+	return to.TrySetBytes(ele.Bytes())
+}
+
+// tryMapArray decodes arr into elements made by newElement.
+func tryMapArray(arr []Element, newElement func() Element) ([]Element, error) {
+	var curveArr []Element
+	for i := 0; i < len(arr); i++ {
+		ele, err := tryMap(arr[i], newElement())
+		if err != nil {
+			return nil, err
+		}
+		curveArr = append(curveArr, ele)
+	}
+	return curveArr, nil
+}
+
+// newProofElement returns an element of the group holding proofs.
+func (aVRF *abstractVRF) newProofElement() Element {
+	return newProofElement(aVRF.pairing, aVRF.placement)
+}
+
+// newKeyElement returns an element of the group holding public keys.
+func (aVRF *abstractVRF) newKeyElement() Element {
+	return newKeyElement(aVRF.pairing, aVRF.placement)
+}
+
+func newProofElement(pairing Pairing, placement Placement) Element {
+	if placement == PubKeyInG1 && !pairing.IsSymmetric() {
+		return pairing.NewG2()
+	}
+	return pairing.NewG1()
+}
+
+func newKeyElement(pairing Pairing, placement Placement) Element {
+	if placement == PubKeyInG1 || pairing.IsSymmetric() {
+		return pairing.NewG1()
+	}
+	return pairing.NewG2()
+}
+
+// pair computes e(a, b) for a in the proof group and b in the key group.
+func (aVRF *abstractVRF) pair(a, b Element) Element {
+	if aVRF.placement == PubKeyInG1 && !aVRF.pairing.IsSymmetric() {
+		return aVRF.pairing.NewGT().Pair(b, a)
+	}
+	return aVRF.pairing.NewGT().Pair(a, b)
+}
@@ -2,58 +2,91 @@ package vrf
 
 import (
 	"math/big"
-	"github.com/Nik-U/pbc"
 )
 
 func (vrf *abstractVRF) BMR10GenNewPubKey() {
-	var newPubKey []*pbc.Element
+	var newPubKey []Element
 	newPubKey = append(newPubKey, vrf.secKey[0])
 	for i := 1; i < vrf.lCode + 1; i ++ {
-		newPubKey = append(newPubKey, vrf.pairing.NewG1().PowZn(vrf.g, vrf.secKey[i]))
+		newPubKey = append(newPubKey, vrf.newKeyElement().PowZn(vrf.gk, vrf.secKey[i]))
 	}
 	vrf.pubKey = newPubKey
 }
 
-// ****** Generation ******
-// - In: lambda
-// - Out: None
-// * Set length
+// ****** Set Length ******
 //		lIn: length of input
 //		lCode: length of code
-// * Generate Group Parameters
-// 		params: group parameters
-// 		pairing: pair in group
-// 		g: group generator
+func (vrf *abstractVRF) BMR10SetLength() {
+	vrf.lCode, vrf.lIn = 71, 64
+}
+
+// ****** Generation ******
+// - In: lambda (security level in bits), opts (curve family or existing params)
+// - Out: None
+// Generate Group Parameters with NewParams, then the keys with BMR10GenKey.
+func (vrf *abstractVRF) BMR10Gen(lambda uint32, opts ...GenOption) {
+	opts = vrf.genDefaults(opts)
+	params, err := NewParams(lambda, opts...)
+	if err != nil {
+		panic(err)
+	}
+	if err := vrf.BMR10GenKey(params, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// ****** Key Generation ******
+// - In: params (shared group parameters), opts (WithRand, WithSeed, WithProgress, WithKeyProof)
+// - Out: error (cancelled, or the reader failed), vrf is left unchanged then
+// * Use Group Parameters
+// 		params, pairing, g, gk: taken from params
+//		lIn, lCode: set by BMR10SetLength
 //	* Generate Keys
 // 		secKey: secret key
-// 			sk = ([r], u) or sk = (h, u[1], ..., u[n]) where n = lCode
+// 			sk = ([r], u) or sk = (h, u[1], ..., u[n]) where n = lCode, h in key group
+//			h: params.H when derived from a public seed
 // 		pubKey: public key
-//			pk = ([r], [u]) or sk = (h, g^u[1], ..., g^u[n])
-func (vrf *abstractVRF) BMR10Gen(lambda uint32) {
-	// Set length
-	vrf.lCode, vrf.lIn = 71, 64
-
-	// Generate Group Parameters
-	vrf.params = pbc.GenerateA(lambda, 2 * lambda)
-	vrf.pairing = pbc.NewPairing(vrf.params)
-	vrf.g = vrf.pairing.NewG1().Rand()
+//			pk = ([r], [u]) or sk = (h, gk^u[1], ..., gk^u[n])
+func (vrf *abstractVRF) BMR10GenKey(params *Params, opts ...GenOption) error {
+	// Use Group Parameters, on a VRF of its own: vrf keeps its params and
+	// keys until the new ones are complete
+	key := vrf.keyVRF(params)
 
 	// Generate Keys
-	var pubKey, secKey []*pbc.Element
-	h := vrf.pairing.NewG1().Rand()
+	o := newGenOptions(opts)
+	r := o.reader(seedKeyDST + "BMR10")
+	var pubKey, secKey []Element
+	h, err := key.keyH(r)
+	if err != nil {
+		return err
+	}
 	pubKey = append(pubKey, h)
 	secKey = append(secKey, h)
-	for i := 1; i < vrf.lCode + 1; i ++ {
-		secKey = append(secKey, vrf.pairing.NewZr().Rand())
-		pubKey = append(pubKey, vrf.pairing.NewG1().PowZn(vrf.g, secKey[i]))
+	for i := 1; i < key.lCode + 1; i ++ {
+		u, err := randZr(key.pairing, r)
+		if err != nil {
+			return err
+		}
+		secKey = append(secKey, u)
+		pubKey = append(pubKey, key.newKeyElement().PowZn(key.gk, secKey[i]))
+		if err := o.report(GenEvent{Stage: GenKeyElement, Index: i, Total: key.lCode}); err != nil {
+			return err
+		}
+	}
+	key.secKey = secKey
+	key.pubKey = pubKey
+	if err := key.genKeyProof(o, r); err != nil {
+		key.Destroy()
+		return err
 	}
-	vrf.secKey = secKey
-	vrf.pubKey = pubKey
+	vrf.setKeys(key)
+	return nil
 }
 
 // ***** Evaluation ******
 // - In:
 //		x: seed
+//		opts: WithHardened blinds the inversions and splits the exponents
 // - Out: 
 //		value: value
 //		proof: proof
@@ -68,7 +101,8 @@ func (vrf *abstractVRF) BMR10Gen(lambda uint32) {
 //		value: e(v[n], h)
 //		proof: (v[0], v[1], ..., v[n])
 
-func (vrf * abstractVRF) BMR10Eval(x *big.Int) (*pbc.Element, []*pbc.Element) {
+func (vrf * abstractVRF) BMR10Eval(x *big.Int, opts ...EvalOption) (Element, []Element) {
+	o := newEvalOptions(opts)
 	// Evaluate 1
 	X := PadLeft(BigToBin(x), vrf.lIn)
 	if len(X) != vrf.lIn {
@@ -80,16 +114,16 @@ func (vrf * abstractVRF) BMR10Eval(x *big.Int) (*pbc.Element, []*pbc.Element) {
 	}
 
 	// Evaluate 2
-	var v []*pbc.Element
-	v = append(v, vrf.pairing.NewG1().Set(vrf.g))
+	var v []Element
+	v = append(v, vrf.newProofElement().Set(vrf.g))
 	for i := 1; i < vrf.lIn + 1; i ++ {
-		c1 := vrf.pairing.NewZr().SetInt32(int32(fx[i - 1] - '0')).ThenAdd(vrf.secKey[i]).ThenInvert()
-		c2 := vrf.pairing.NewG1().PowZn(v[i - 1], c1)
+		c1 := vrf.invert(vrf.pairing.NewZr().SetInt32(int32(fx[i - 1] - '0')).ThenAdd(vrf.secKey[i]), o)
+		c2 := vrf.powProof(v[i - 1], c1, o)
 		v = append(v, c2)
 	}
 
 	// Evaluate 3
-	value := vrf.pairing.NewGT().Pair(v[vrf.lIn], vrf.secKey[0])
+	value := vrf.pair(v[vrf.lIn], vrf.secKey[0])
 	proof := v
 	return value, proof
 }
@@ -104,32 +138,64 @@ func (vrf * abstractVRF) BMR10Eval(x *big.Int) (*pbc.Element, []*pbc.Element) {
 // * Evaluate1 -> encode x
 //		X: binary of x
 //		fx: code(X)
-// * Verify1 -> check e(v[i], g^(fx[i] + u[i])) == e(v[i-1], g)
-//		c1: g^fx[i] . g^(u[i])
+// * Verify0 -> check v[0] == g
+// * Verify1 -> check e(v[i], gk^(fx[i] + u[i])) == e(v[i-1], gk)
+//		c1: gk^fx[i] . gk^(u[i])
 //		c2: e(v[i], c1)
-//		c3: e(v[i-1], g)
-// * Verify2 -> check value = e(v[n], g)
+//		c3: e(v[i-1], gk)
+// * Verify2 -> check value = e(v[n], h)
+// BMR10VerifyDetailed records the failed check (CheckStart, CheckChain with
+// step i, CheckOutput) in res.
 
-func (vrf *abstractVRF) BMR10Verify(x *big.Int, value *pbc.Element, v []*pbc.Element) (bool) {
-	value = vrf.MapElementToCurveT(value)
-	v = vrf.MapArrayToCurve(v)
+func (vrf *abstractVRF) BMR10Verify(x *big.Int, value Element, v []Element) (bool) {
+	return vrf.BMR10VerifyDetailed(x, value, v, &VerifyResult{})
+}
 
+func (vrf *abstractVRF) BMR10VerifyDetailed(x *big.Int, value Element, v []Element, res *VerifyResult) (bool) {
 	// Evaluate 1
+	// a negative x would be encoded with a '-' that HCode reads as 0
+	if x == nil || x.Sign() < 0 {
+		return res.fail(CheckInput, ErrInputDomain)
+	}
 	X := PadLeft(BigToBin(x), vrf.lIn)
+	if len(X) != vrf.lIn {
+		return res.fail(CheckInput, ErrInputDomain)
+	}
 	fx := HCode(X)
+	res.Encoding = fx
+	if len(v) != vrf.lIn + 1 {
+		return res.fail(CheckMalformed, ErrProofLength)
+	}
+	value, err := tryMap(value, vrf.pairing.NewGT())
+	if err != nil {
+		return res.fail(CheckMalformed, err)
+	}
+	if v, err = tryMapArray(v, vrf.newProofElement); err != nil {
+		return res.fail(CheckMalformed, err)
+	}
+
+	// Verify 0
+	if ! res.check(CheckStart, 0, v[0], vrf.g) {
+		return false
+	}
 
 	// Verify 1
 	for i := 1; i < vrf.lIn + 1; i ++ {
-		c1 := vrf.pairing.NewG1().PowZn(vrf.g, vrf.pairing.NewZr().SetInt32(int32(fx[i - 1] - '0'))).ThenMul(vrf.pubKey[i])
-		c2 := vrf.pairing.NewGT().Pair(v[i], c1)
-		c3 := vrf.pairing.NewGT().Pair(v[i - 1], vrf.g)
-		if ! c2.Equals(c3) {
+		c1 := vrf.newKeyElement().PowZn(vrf.gk, vrf.pairing.NewZr().SetInt32(int32(fx[i - 1] - '0'))).ThenMul(vrf.pubKey[i])
+		c2 := vrf.pair(v[i], c1)
+		c3 := vrf.pair(v[i - 1], vrf.gk)
+		res.term("fx[i]", fx[i - 1:i])
+		res.term("v[i-1]", v[i - 1])
+		res.term("v[i]", v[i])
+		res.term("gk^fx[i] . gk^u[i]", c1)
+		if ! res.check(CheckChain, i, c2, c3) {
 			return false
 		}
 	}
 
 	// Verify 2
-	if ! value.Equals(vrf.pairing.NewGT().Pair(v[vrf.lIn], vrf.pubKey[0])) {
+	res.term("v[n]", v[vrf.lIn])
+	if ! res.check(CheckOutput, 0, value, vrf.pair(v[vrf.lIn], vrf.pubKey[0])) {
 		return false
 	}
 	return true
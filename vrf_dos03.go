@@ -2,54 +2,86 @@ package vrf
 
 import (
 	"math/big"
-
-	"github.com/Nik-U/pbc"
+	"strings"
 )
 
 func (vrf *abstractVRF) DOD03GenNewPubKey() {
-	var newPubKey []*pbc.Element
+	var newPubKey []Element
 	newPubKey = append(newPubKey, vrf.secKey[0])
 	for i := 1; i < vrf.lCode+1; i++ {
-		newPubKey = append(newPubKey, vrf.pairing.NewG1().PowZn(vrf.secKey[0], vrf.secKey[i]))
+		newPubKey = append(newPubKey, vrf.newKeyElement().PowZn(vrf.secKey[0], vrf.secKey[i]))
 	}
 	vrf.pubKey = newPubKey
 }
 
-// ****** Generation ******
-// - In: lambda
-// - Out: None
-// * Set length
+// ****** Set Length ******
 //		lIn: length of input
 //		lCode: length of code
-// * Generate Group Parameters
-// 		params: group parameters
-// 		pairing: pair in group
-// 		g: group generator
+func (vrf *abstractVRF) DOD03SetLength() {
+	vrf.lCode, vrf.lIn = 71, 64
+}
+
+// ****** Generation ******
+// - In: lambda (security level in bits), opts (curve family or existing params)
+// - Out: None
+// Generate Group Parameters with NewParams, then the keys with DOD03GenKey.
+func (vrf *abstractVRF) DOD03Gen(lambda uint32, opts ...GenOption) {
+	opts = vrf.genDefaults(opts)
+	params, err := NewParams(lambda, opts...)
+	if err != nil {
+		panic(err)
+	}
+	if err := vrf.DOD03GenKey(params, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// ****** Key Generation ******
+// - In: params (shared group parameters), opts (WithRand, WithSeed, WithProgress, WithKeyProof)
+// - Out: error (cancelled, or the reader failed), vrf is left unchanged then
+// * Use Group Parameters
+// 		params, pairing, g, gk: taken from params
+//		lIn, lCode: set by DOD03SetLength
 //	* Generate Keys
 // 		secKey: secret key
-// 			sk = ([r], u) or sk = (h, u[1], ..., u[n]) where n = lCode
+// 			sk = ([r], u) or sk = (h, u[1], ..., u[n]) where n = lCode, h in key group
+//			h: params.H when derived from a public seed
 // 		pubKey: public key
 //			pk = ([r], [u]) or sk = (h, h^u[1], ..., h^u[n])
-func (vrf *abstractVRF) DOD03Gen(lambda uint32) {
-	// Set length
-	vrf.lCode, vrf.lIn = 71, 64
-
-	// Generate Group Parameters
-	vrf.params = pbc.GenerateA(lambda, 2*lambda)
-	vrf.pairing = pbc.NewPairing(vrf.params)
-	vrf.g = vrf.pairing.NewG1().Rand()
+func (vrf *abstractVRF) DOD03GenKey(params *Params, opts ...GenOption) error {
+	// Use Group Parameters, on a VRF of its own: vrf keeps its params and
+	// keys until the new ones are complete
+	key := vrf.keyVRF(params)
 
 	// Generate Keys
-	h := vrf.pairing.NewG1().Rand()
-	var secKey, pubKey []*pbc.Element
+	o := newGenOptions(opts)
+	r := o.reader(seedKeyDST + "DOD03")
+	h, err := key.keyH(r)
+	if err != nil {
+		return err
+	}
+	var secKey, pubKey []Element
 	secKey = append(secKey, h)
 	pubKey = append(pubKey, h)
-	for i := 1; i < vrf.lCode+1; i++ {
-		secKey = append(secKey, vrf.pairing.NewZr().Rand())
-		pubKey = append(pubKey, vrf.pairing.NewG1().PowZn(h, secKey[i]))
+	for i := 1; i < key.lCode+1; i++ {
+		u, err := randZr(key.pairing, r)
+		if err != nil {
+			return err
+		}
+		secKey = append(secKey, u)
+		pubKey = append(pubKey, key.newKeyElement().PowZn(h, secKey[i]))
+		if err := o.report(GenEvent{Stage: GenKeyElement, Index: i, Total: key.lCode}); err != nil {
+			return err
+		}
+	}
+	key.secKey = secKey
+	key.pubKey = pubKey
+	if err := key.genKeyProof(o, r); err != nil {
+		key.Destroy()
+		return err
 	}
-	vrf.secKey = secKey
-	vrf.pubKey = pubKey
+	vrf.setKeys(key)
+	return nil
 }
 
 // ***** Evaluation ******
@@ -63,10 +95,12 @@ func (vrf *abstractVRF) DOD03Gen(lambda uint32) {
 //		fx: code(X)
 // * Evaluate 2 -> value, proof
 //		v[i]: v[i-1] * u[i] if fx[i] == 1 else v[i-1]
+//		hardened: v[i-1] * (1 + fx[i] (u[i] - 1)), without branching
 // * Evaluate 3 -> value, proof
 //		value: v[n]
 //		proof: (v[0], v[1], ..., v[n])
-func (vrf *abstractVRF) DOD03Eval(x *big.Int) (*pbc.Element, []*pbc.Element) {
+func (vrf *abstractVRF) DOD03Eval(x *big.Int, opts ...EvalOption) (Element, []Element) {
+	o := newEvalOptions(opts)
 	// Evaluate 1
 	X := PadLeft(BigToBin(x), vrf.lIn)
 	if len(X) != vrf.lIn {
@@ -77,13 +111,18 @@ func (vrf *abstractVRF) DOD03Eval(x *big.Int) (*pbc.Element, []*pbc.Element) {
 		panic("...")
 	}
 	// Evaluate 2
-	var v []*pbc.Element
-	v = append(v, vrf.pairing.NewG1().Set(vrf.g))
+	var v []Element
+	v = append(v, vrf.newProofElement().Set(vrf.g))
+	one := vrf.pairing.NewZr().Set1()
 	for i := 1; i < vrf.lCode+1; i++ {
-		if fx[i-1] == '1' {
-			v = append(v, vrf.pairing.NewG1().PowZn(v[i-1], vrf.secKey[i]))
+		if o.hardened {
+			bit := vrf.pairing.NewZr().SetInt32(int32(fx[i-1] - '0'))
+			e := vrf.pairing.NewZr().Sub(vrf.secKey[i], one).ThenMul(bit).ThenAdd(one)
+			v = append(v, vrf.powProof(v[i-1], e, o))
+		} else if fx[i-1] == '1' {
+			v = append(v, vrf.newProofElement().PowZn(v[i-1], vrf.secKey[i]))
 		} else {
-			v = append(v, vrf.pairing.NewG1().Set(v[i-1]))
+			v = append(v, vrf.newProofElement().Set(v[i-1]))
 		}
 	}
 	// Evaluate 3
@@ -102,35 +141,227 @@ func (vrf *abstractVRF) DOD03Eval(x *big.Int) (*pbc.Element, []*pbc.Element) {
 // * Evaluate1 -> encode x
 //		X: binary of x
 //		fx: code(X)
-// * Verify -> check e(v[i], h) == e(v[i-1], h^u[i] if fx[i] == 1 else h)
+// * Verify0 -> check v[0] == g
+// * Verify1 -> check e(v[i], h) == e(v[i-1], h^u[i] if fx[i] == 1 else h)
 //		c1: e(v[i-1], h^u[i] if fx[i] == 1 else h)
 //		c2: e(v[i], h)
-func (vrf *abstractVRF) DOD03Verify(x *big.Int, y *pbc.Element, v []*pbc.Element) bool {
-	y = vrf.MapElementToCurveT(y)
-	v = vrf.MapArrayToCurve(v)
+// * Verify2 -> check y == v[n]
+// y is decoded in the proof group, where DOD03Eval computes it: the value of
+// DOD03 is the end of the chain, not a pairing. It used to be decoded in GT
+// and then never compared, so any y verified; on bn256 the GT decoding of a
+// G1 point fails.
+// DOD03VerifyDetailed records the failed check (CheckStart, CheckChain with
+// step i, CheckOutput) in res.
+func (vrf *abstractVRF) DOD03Verify(x *big.Int, y Element, v []Element) bool {
+	return vrf.DOD03VerifyDetailed(x, y, v, &VerifyResult{})
+}
 
+func (vrf *abstractVRF) DOD03VerifyDetailed(x *big.Int, y Element, v []Element, res *VerifyResult) bool {
 	// Evaluate 1
+	// a negative x would be encoded with a '-' that HCode reads as 0
+	if x == nil || x.Sign() < 0 {
+		return res.fail(CheckInput, ErrInputDomain)
+	}
 	X := PadLeft(BigToBin(x), vrf.lIn)
 	if len(X) != vrf.lIn {
-		panic("...")
+		return res.fail(CheckInput, ErrInputDomain)
 	}
 	fx := HCode(X)
-	if len(fx) != vrf.lCode {
-		panic("...")
+	res.Encoding = fx
+	if len(fx) != vrf.lCode || len(v) != vrf.lCode+1 {
+		return res.fail(CheckMalformed, ErrProofLength)
+	}
+	y, err := tryMap(y, vrf.newProofElement())
+	if err != nil {
+		return res.fail(CheckMalformed, err)
+	}
+	if v, err = tryMapArray(v, vrf.newProofElement); err != nil {
+		return res.fail(CheckMalformed, err)
+	}
+
+	// Verify 0
+	if !res.check(CheckStart, 0, v[0], vrf.g) {
+		return false
 	}
 
-	// Verify
+	// Verify 1
 	for i := 1; i < vrf.lCode+1; i++ {
-		var c1 *pbc.Element
+		key := vrf.pubKey[0]
+		if fx[i-1] == '1' {
+			key = vrf.pubKey[i]
+		}
+		c1 := vrf.pair(v[i-1], key)
+		c2 := vrf.pair(v[i], vrf.pubKey[0])
+		res.term("fx[i]", fx[i-1:i])
+		res.term("v[i-1]", v[i-1])
+		res.term("v[i]", v[i])
+		res.term("h^u[i] or h", key)
+		if !res.check(CheckChain, i, c1, c2) {
+			return false
+		}
+	}
+
+	// Verify 2
+	return res.check(CheckOutput, 0, y, v[vrf.lCode])
+}
+
+// ***** Compact Proof *****
+// v[i] == v[i-1] whenever fx[i] == 0, and v[0] == g is public, so the
+// compact proof only carries the chain elements at positions where the
+// codeword bit is 1. The verifier recomputes fx from x and fills in the
+// copied positions itself.
+// - In:
+//		x: seed
+//		v: full proof (v[0], v[1], ..., v[n])
+// - Out:
+//		w: compact proof (v[i] for every i with fx[i] == 1), nil if x is
+//		   outside the input domain or v is not a full proof
+func (vrf *abstractVRF) DOD03CompactProof(x *big.Int, v []Element) []Element {
+	// Evaluate 1
+	if x == nil || x.Sign() < 0 {
+		return nil
+	}
+	X := PadLeft(BigToBin(x), vrf.lIn)
+	if len(X) != vrf.lIn {
+		return nil
+	}
+	fx := HCode(X)
+	if len(fx) != vrf.lCode || len(v) != vrf.lCode+1 {
+		return nil
+	}
+
+	var w []Element
+	for i := 1; i < vrf.lCode+1; i++ {
+		if fx[i-1] == '1' {
+			w = append(w, v[i])
+		}
+	}
+	return w
+}
+
+// ***** Expand Proof *****
+// - In:
+//		x: seed
+//		w: compact proof
+// - Out:
+//		v: full proof (v[0], v[1], ..., v[n]), nil if w does not match fx or
+//		   does not decode
+// * Expand -> v[i]: w[j++] if fx[i] == 1 else v[i-1]
+func (vrf *abstractVRF) DOD03ExpandProof(x *big.Int, w []Element) (v []Element) {
+	// Evaluate 1
+	if x == nil || x.Sign() < 0 {
+		return nil
+	}
+	X := PadLeft(BigToBin(x), vrf.lIn)
+	if len(X) != vrf.lIn {
+		return nil
+	}
+	fx := HCode(X)
+	if len(fx) != vrf.lCode || len(w) != strings.Count(fx, "1") {
+		return nil
+	}
+	w, err := tryMapArray(w, vrf.newProofElement)
+	if err != nil {
+		return nil
+	}
+
+	// Expand
+	v = append(v, vrf.newProofElement().Set(vrf.g))
+	for i, j := 1, 0; i < vrf.lCode+1; i++ {
 		if fx[i-1] == '1' {
-			c1 = vrf.pairing.NewGT().Pair(v[i-1], vrf.pubKey[i])
+			v = append(v, w[j])
+			j++
 		} else {
-			c1 = vrf.pairing.NewGT().Pair(v[i-1], vrf.pubKey[0])
+			v = append(v, vrf.newProofElement().Set(v[i-1]))
+		}
+	}
+	return v
+}
+
+// ***** Compact Evaluation ******
+// - In:
+//		x: seed
+// - Out:
+//		value: value
+//		proof: compact proof
+// Same as DOD03Eval, with the proof shortened by DOD03CompactProof.
+func (vrf *abstractVRF) DOD03EvalCompact(x *big.Int, opts ...EvalOption) (Element, []Element) {
+	value, proof := vrf.DOD03Eval(x, opts...)
+	return value, vrf.DOD03CompactProof(x, proof)
+}
+
+// ***** Compact Verification *****
+// - In:
+//		x: seed
+//		value: value
+//		proof: compact proof
+// - Out:
+//		0/1 or valid/invalid
+// * Evaluate1 -> encode x
+//		X: binary of x
+//		fx: code(X)
+// * Verify1 -> check e(w[j], h) == e(w[j-1], h^u[i]) for every i with fx[i] == 1
+//		w[-1]: g
+//		c1: e(w[j-1], h^u[i])
+//		c2: e(w[j], h)
+// * Verify2 -> check value == w[last] (or g when fx has no 1 bits)
+// DOD03VerifyCompactDetailed explains the result like VerifyDetailed, with
+// CheckChain at the code position i. Like DOD03EvalCompact, it takes x as
+// is, without the input policy.
+func (vrf *abstractVRF) DOD03VerifyCompact(x *big.Int, y Element, w []Element) bool {
+	return vrf.DOD03VerifyCompactDetailed(x, y, w).Valid
+}
+
+func (vrf *abstractVRF) DOD03VerifyCompactDetailed(x *big.Int, y Element, w []Element, opts ...VerifyOption) (res *VerifyResult) {
+	if len(vrf.pubKey) != vrf.pubKeyLength() {
+		panic("vrf: Verify without a public key")
+	}
+	res = newVerifyResult(vrf.typeVRF, opts)
+	res.Input = x
+	res.Valid = vrf.dod03VerifyCompact(x, y, w, res)
+	return res
+}
+
+func (vrf *abstractVRF) dod03VerifyCompact(x *big.Int, y Element, w []Element, res *VerifyResult) bool {
+	// Evaluate 1
+	if x == nil || x.Sign() < 0 {
+		return res.fail(CheckInput, ErrInputDomain)
+	}
+	X := PadLeft(BigToBin(x), vrf.lIn)
+	if len(X) != vrf.lIn {
+		return res.fail(CheckInput, ErrInputDomain)
+	}
+	fx := HCode(X)
+	res.Encoding = fx
+	if len(fx) != vrf.lCode || len(w) != strings.Count(fx, "1") {
+		return res.fail(CheckMalformed, ErrProofLength)
+	}
+	y, err := tryMap(y, vrf.newProofElement())
+	if err != nil {
+		return res.fail(CheckMalformed, err)
+	}
+	if w, err = tryMapArray(w, vrf.newProofElement); err != nil {
+		return res.fail(CheckMalformed, err)
+	}
+
+	// Verify 1
+	prev := vrf.g
+	for i, j := 1, 0; i < vrf.lCode+1; i++ {
+		if fx[i-1] != '1' {
+			continue
 		}
-		c2 := vrf.pairing.NewGT().Pair(v[i], vrf.pubKey[0])
-		if !c1.Equals(c2) {
+		c1 := vrf.pair(prev, vrf.pubKey[i])
+		c2 := vrf.pair(w[j], vrf.pubKey[0])
+		res.term("w[j-1]", prev)
+		res.term("w[j]", w[j])
+		res.term("h^u[i]", vrf.pubKey[i])
+		if !res.check(CheckChain, i, c1, c2) {
 			return false
 		}
+		prev = w[j]
+		j++
 	}
-	return true
+
+	// Verify 2
+	return res.check(CheckOutput, 0, y, prev)
 }
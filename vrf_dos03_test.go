@@ -0,0 +1,95 @@
+package vrf
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDOD03Compact(t *testing.T) {
+	aVRF := newTestVRF(t, "DOD03", "bn256")
+	x := big.NewInt(100)
+	value, w := aVRF.DOD03EvalCompact(x)
+	_, full := aVRF.DOD03Eval(x)
+
+	if !aVRF.DOD03VerifyCompact(x, value, w) {
+		t.Fatal("compact proof does not verify")
+	}
+	v := aVRF.DOD03ExpandProof(x, w)
+	if len(v) != len(full) {
+		t.Fatalf("expanded proof has %d elements, want %d", len(v), len(full))
+	}
+	for i := range v {
+		if !v[i].Equals(full[i]) {
+			t.Fatalf("expanded proof differs at %d", i)
+		}
+	}
+	if !aVRF.Verify(x, value, v) {
+		t.Error("expanded proof does not verify")
+	}
+}
+
+func TestDOD03CompactMalformed(t *testing.T) {
+	aVRF := newTestVRF(t, "DOD03", "bn256")
+	x := big.NewInt(100)
+	value, w := aVRF.DOD03EvalCompact(x)
+
+	extra := aVRF.newProofElement().Set(w[0])
+	other := aVRF.newProofElement().SetFromHash([]byte("value"))
+	swapped := append([]Element{}, w...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	tests := []struct {
+		name    string
+		x       *big.Int
+		value   Element
+		w       []Element
+		kind    CheckKind
+		expands bool // w itself is well formed
+	}{
+		{"short", x, value, w[1:], CheckMalformed, false},
+		{"long", x, value, append(append([]Element{}, w...), extra), CheckMalformed, false},
+		{"empty", x, value, nil, CheckMalformed, false},
+		{"nil element", x, value, append([]Element{nil}, w[1:]...), CheckMalformed, false},
+		{"element of Zr", x, value, append([]Element{aVRF.pairing.NewZr()}, w[1:]...), CheckMalformed, false},
+		{"value of GT", x, aVRF.pairing.NewGT(), w, CheckMalformed, true},
+		{"nil value", x, nil, w, CheckMalformed, true},
+		{"swapped", x, value, swapped, CheckChain, true},
+		{"other value", x, other, w, CheckOutput, true},
+		{"negative input", big.NewInt(-100), value, w, CheckInput, false},
+		{"nil input", nil, value, w, CheckInput, false},
+		{"long input", new(big.Int).Lsh(big.NewInt(1), 64), value, w, CheckInput, false},
+	}
+	for _, test := range tests {
+		res := aVRF.DOD03VerifyCompactDetailed(test.x, test.value, test.w)
+		if res.Valid || res.Kind != test.kind {
+			t.Errorf("%s: %v, want %v", test.name, res, test.kind)
+		}
+		if aVRF.DOD03VerifyCompact(test.x, test.value, test.w) {
+			t.Errorf("%s: DOD03VerifyCompact accepts", test.name)
+		}
+		if expanded := aVRF.DOD03ExpandProof(test.x, test.w) != nil; expanded != test.expands {
+			t.Errorf("%s: DOD03ExpandProof expands: %v", test.name, expanded)
+		}
+	}
+}
+
+func TestDOD03CompactProofMalformed(t *testing.T) {
+	aVRF := newTestVRF(t, "DOD03", "bn256")
+	x := big.NewInt(100)
+	_, v := aVRF.DOD03Eval(x)
+	tests := []struct {
+		name string
+		x    *big.Int
+		v    []Element
+	}{
+		{"nil input", nil, v},
+		{"negative input", big.NewInt(-100), v},
+		{"long input", new(big.Int).Lsh(big.NewInt(1), 64), v},
+		{"short proof", x, v[1:]},
+		{"empty proof", x, nil},
+	}
+	for _, test := range tests {
+		if w := aVRF.DOD03CompactProof(test.x, test.v); w != nil {
+			t.Errorf("%s: compacted to %d elements", test.name, len(w))
+		}
+	}
+}
@@ -1,46 +1,238 @@
 package vrf
 
 import (
+	"encoding/binary"
+	"errors"
 	"math/big"
-
-	"github.com/Nik-U/pbc"
+	"strings"
 )
 
 func (vrf *abstractVRF) DY05GenNewPubKey() {
-	var newPubKey []*pbc.Element
-	newPubKey = append(newPubKey, vrf.pairing.NewG1().PowZn(vrf.g, vrf.secKey[0]))
+	var newPubKey []Element
+	newPubKey = append(newPubKey, vrf.newKeyElement().PowZn(vrf.gk, vrf.secKey[0]))
 	vrf.pubKey = newPubKey
 }
 
+// ****** Set Length ******
+// DY05 takes x as a whole, there is no code
+func (vrf *abstractVRF) DY05SetLength() {
+	vrf.lCode, vrf.lIn = 0, 0
+}
+
+// ****** Input Policy ******
+// x is used as an element of Zr, so x and x + r collide, and the q-DBDHI
+// assumption behind DY05 only covers a small input domain [0, 2^k). Without a
+// policy x must lie in [0, r), other inputs are refused with ErrInputDomain
+// instead of being reduced mod r. With one, k = Bits (default
+// log2(r) - 1, ErrInputBits above that) and x is either
+// * refused with ErrInputDomain unless 0 <= x < 2^k, or
+// * with Hash, always hashed into [0, 2^k): sign and magnitude of x go
+//   through expand_message_xmd under DY05InputDST and are cut to k bits.
+// BMR10 and DOD03 apply the same policy with k = lIn, under CodeInputDST;
+// without one they refuse inputs outside [0, 2^lIn).
+
+const (
+	DY05InputDST = "VRF-V01-DY05-INPUT_XMD:SHA-256_"
+	CodeInputDST = "VRF-V01-CODE-INPUT_XMD:SHA-256_"
+)
+
+var (
+	ErrInputDomain = errors.New("vrf: input outside the input domain")
+	ErrInputBits   = errors.New("vrf: input domain not below the group order")
+)
+
+// InputPolicy restricts the inputs of a VRF.
+type InputPolicy struct {
+	Bits int  `json:"bits"` // k, 0 for log2(r) - 1
+	Hash bool `json:"hash"` // hash inputs into the domain instead of refusing them
+}
+
+// SetInputPolicy sets the input policy, nil for none.
+func (vrf *abstractVRF) SetInputPolicy(policy *InputPolicy) {
+	vrf.input = policy
+}
+
+// - In: x: input
+// - Out: x as evaluated, ErrInputDomain when it is refused
+func (vrf *abstractVRF) DY05Input(x *big.Int) (*big.Int, error) {
+	if vrf.input == nil {
+		if x == nil || vrf.pairing.NewZr().SetBig(x).BigInt().Cmp(x) != 0 {
+			return nil, ErrInputDomain
+		}
+		return x, nil
+	}
+	info, err := ParamsInfoOf(vrf.params)
+	if err != nil {
+		return nil, err
+	}
+	k := vrf.input.Bits
+	if k <= 0 {
+		k = info.RBits - 1
+	}
+	if k > info.RBits-1 {
+		return nil, ErrInputBits
+	}
+	return vrf.input.apply(x, k, DY05InputDST)
+}
+
+// apply refuses x outside [0, 2^k), or hashes it into [0, 2^k) under dst.
+// A nil x is always refused.
+func (policy *InputPolicy) apply(x *big.Int, k int, dst string) (*big.Int, error) {
+	if x == nil {
+		return nil, ErrInputDomain
+	}
+	if !policy.Hash {
+		if x.Sign() < 0 || x.BitLen() > k {
+			return nil, ErrInputDomain
+		}
+		return x, nil
+	}
+
+	msg := append([]byte{byte(x.Sign() + 1)}, x.Bytes()...)
+	uniform, err := expandMessageXMD(msg, []byte(dst), (k+7)/8)
+	if err != nil {
+		return nil, err
+	}
+	h := new(big.Int).SetBytes(uniform)
+	return h.Mod(h, new(big.Int).Lsh(big.NewInt(1), uint(k))), nil
+}
+
 // ****** Generation ******
-// - In: lambda
+// - In: lambda (security level in bits), opts (curve family or existing params)
 // - Out: None
-// Generate Group Parameters
-// 		params: group parameters
-// 		pairing: pair in group
-// 		g: group generator
+// Generate Group Parameters with NewParams, then the keys with DY05GenKey.
+func (vrf *abstractVRF) DY05Gen(lambda uint32, opts ...GenOption) {
+	opts = vrf.genDefaults(opts)
+	params, err := NewParams(lambda, opts...)
+	if err != nil {
+		panic(err)
+	}
+	if err := vrf.DY05GenKey(params, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// ****** Key Generation ******
+// - In: params (shared group parameters), opts (WithRand, WithSeed, WithProgress, WithKeyProof)
+// - Out: error (cancelled, or the reader failed), vrf is left unchanged then
+// Use Group Parameters
+// 		params, pairing, g, gk: taken from params
 //	Generate Keys
 // 		secKey: secret key
 // 			sk = r
 // 		pubKey: public key
-//			pk = [r] or sk = g^r
-func (vrf *abstractVRF) DY05Gen(lambda uint32) {
-	// Generate Group Parameters
-	vrf.params = pbc.GenerateA(lambda, 2*lambda)
-	vrf.pairing = pbc.NewPairing(vrf.params)
-	vrf.g = vrf.pairing.NewG1().Rand()
+//			pk = [r] or sk = gk^r
+func (vrf *abstractVRF) DY05GenKey(params *Params, opts ...GenOption) error {
+	// Use Group Parameters, on a VRF of its own: vrf keeps its params and
+	// keys until the new ones are complete
+	key := vrf.keyVRF(params)
 
 	// Generate Keys
-	var secKey, pubKey []*pbc.Element
-	secKey = append(secKey, vrf.pairing.NewZr().Rand())
-	vrf.secKey = secKey
-	pubKey = append(pubKey, vrf.pairing.NewG1().PowZn(vrf.g, vrf.secKey[0]))
-	vrf.pubKey = pubKey
+	o := newGenOptions(opts)
+	r := o.reader(seedKeyDST + "DY05")
+	var secKey, pubKey []Element
+	u, err := randZr(key.pairing, r)
+	if err != nil {
+		return err
+	}
+	secKey = append(secKey, u)
+	pubKey = append(pubKey, key.newKeyElement().PowZn(key.gk, secKey[0]))
+	if err := o.report(GenEvent{Stage: GenKeyElement, Index: 1, Total: 1}); err != nil {
+		return err
+	}
+	key.secKey = secKey
+	key.pubKey = pubKey
+	if err := key.genKeyProof(o, r); err != nil {
+		key.Destroy()
+		return err
+	}
+	vrf.setKeys(key)
+	return nil
+}
+
+// ****** Child Key Derivation ******
+// pk = gk^r, so the child key r + t, t = H(pk, path), has the public key
+// pk . gk^t: secret and public keys derive the same child, the public one
+// without the secret. A path is a "/" separated list of names, one step per
+// name, so "a/b" is the child "b" of the child "a".
+// * Step: t = hash_to_field(len(pk) || pk || len(name) || name, DY05DeriveDST)
+//		child sk: r + t
+//		child pk: pk . gk^t
+// Like non-hardened BIP32, a child secret key together with the public key
+// of its parent gives the secret key of the parent. The output of a child
+// on x is the output of its parent on x + t, so children hash their inputs:
+// they get the input policy of the parent with Hash set. An output of a
+// child is then the output of its parent, or of a sibling, only on an input
+// whose hash is off by t, a preimage. A parent without a hashing policy
+// evaluates x + t itself, give it one (WithHash) when its outputs are used
+// next to those of its children.
+
+const DY05DeriveDST = "VRF-V01-DY05-DERIVE_XMD:SHA-256_RO_"
+
+var (
+	ErrDerivePath   = errors.New("vrf: invalid derivation path")
+	ErrDeriveScheme = errors.New("vrf: scheme has no child keys")
+)
+
+// - In: path: names separated by "/"
+// - Out: the child VRF, with a secret key when vrf has one, hashing its
+//   inputs
+func (vrf *abstractVRF) DY05DeriveChild(path string) (*abstractVRF, error) {
+	if path == "" || len(vrf.pubKey) != 1 {
+		return nil, ErrDerivePath
+	}
+	names := strings.Split(path, "/")
+	for _, name := range names {
+		if name == "" {
+			return nil, ErrDerivePath
+		}
+	}
+
+	input := &InputPolicy{Hash: true}
+	if vrf.input != nil {
+		input.Bits = vrf.input.Bits
+	}
+	child := &abstractVRF{
+		typeVRF: vrf.typeVRF,
+		opts:    vrf.opts,
+		input:   input,
+		backend: vrf.backend,
+	}
+	child.UseParams(vrf.Params())
+	pk := child.newKeyElement().Set(vrf.pubKey[0])
+	var sk Element
+	if vrf.hasSecKey() {
+		vrf.withSecKey(func() {
+			sk = vrf.pairing.NewZr().Set(vrf.secKey[0])
+		})
+	}
+	for _, name := range names {
+		var msg []byte
+		msg = binary.BigEndian.AppendUint32(msg, uint32(len(pk.Bytes())))
+		msg = append(msg, pk.Bytes()...)
+		msg = binary.BigEndian.AppendUint32(msg, uint32(len(name)))
+		msg = append(msg, name...)
+		t, err := child.HashToZr([]byte(DY05DeriveDST), msg)
+		if err != nil {
+			return nil, err
+		}
+		pk.ThenMul(child.newKeyElement().PowZn(child.gk, t))
+		if sk != nil {
+			sk.ThenAdd(t)
+		}
+		zeroize(t)
+	}
+	child.pubKey = []Element{pk}
+	if sk != nil {
+		child.secKey = []Element{sk}
+	}
+	return child, nil
 }
 
 // ***** Evaluation ******
 // - In:
 //		x: seed
+//		opts: WithHardened blinds the inversion and splits the exponent
 // - Out:
 //		value: value
 //		proof: proof
@@ -49,19 +241,20 @@ func (vrf *abstractVRF) DY05Gen(lambda uint32) {
 //		t: 1/(X+r)
 //		gt: g^t
 // Evaluate 2 -> value, proof
-//		value: e(g, gt)
+//		value: e(gt, gk)
 //		proof: gt
 
-func (vrf *abstractVRF) DY05Eval(x *big.Int) (*pbc.Element, []*pbc.Element) {
+func (vrf *abstractVRF) DY05Eval(x *big.Int, opts ...EvalOption) (Element, []Element) {
+	o := newEvalOptions(opts)
 	// Evaluate 1
 	X := vrf.pairing.NewZr().SetBig(x)
-	t := vrf.pairing.NewZr().Add(X, vrf.secKey[0]).ThenInvert()
-	gt := vrf.pairing.NewG1().PowZn(vrf.g, t)
+	t := vrf.invert(vrf.pairing.NewZr().Add(X, vrf.secKey[0]), o)
+	gt := vrf.powProof(vrf.g, t, o)
 
 	// Evaluate 2
-	var value *pbc.Element
-	var proof []*pbc.Element
-	value = vrf.pairing.NewGT().Pair(vrf.g, gt)
+	var value Element
+	var proof []Element
+	value = vrf.pair(gt, vrf.gk)
 	proof = append(proof, gt)
 
 	return value, proof
@@ -74,32 +267,50 @@ func (vrf *abstractVRF) DY05Eval(x *big.Int) (*pbc.Element, []*pbc.Element) {
 //		proof: proof
 // - Out:
 //		0/1 or valid/invalid
-// Verify1 -> check e(g^x . g^r, g^(1/(x+r))) == e(g, g)
+// Verify1 -> check e(g^(1/(x+r)), gk^x . gk^r) == e(g, gk)
 //		X: x to Zr
-//		gx: g^x
-//		c1: e(g^x . g^r, g^(1/(x+r)))
-//		c2: e(g, g)
-// Verify2 -> check value == e(g^(1/(x+r)), g)
-//		gt: g^(9)1/(x+r))
-//		c3: e(e(g^(1/(x+r)), g))
+//		gx: gk^x
+//		c1: e(g^(1/(x+r)), gk^x . gk^r)
+//		c2: e(g, gk)
+// Verify2 -> check value == e(g^(1/(x+r)), gk)
+//		gt: g^(1/(x+r))
+//		c3: e(g^(1/(x+r)), gk)
+// DY05VerifyDetailed records the failed check (CheckKey, CheckOutput) in res.
 
-func (vrf *abstractVRF) DY05Verify(x *big.Int, value *pbc.Element, proof []*pbc.Element) bool {
-	value = vrf.MapElementToCurveT(value)
-	proof = vrf.MapArrayToCurve(proof)
+func (vrf *abstractVRF) DY05Verify(x *big.Int, value Element, proof []Element) bool {
+	return vrf.DY05VerifyDetailed(x, value, proof, &VerifyResult{})
+}
+
+func (vrf *abstractVRF) DY05VerifyDetailed(x *big.Int, value Element, proof []Element, res *VerifyResult) bool {
+	if x == nil {
+		return res.fail(CheckInput, ErrInputDomain)
+	}
+	if len(proof) != 1 {
+		return res.fail(CheckMalformed, ErrProofLength)
+	}
+	value, err := tryMap(value, vrf.pairing.NewGT())
+	if err != nil {
+		return res.fail(CheckMalformed, err)
+	}
+	if proof, err = tryMapArray(proof, vrf.newProofElement); err != nil {
+		return res.fail(CheckMalformed, err)
+	}
 
 	X := vrf.pairing.NewZr().SetBig(x)
 	// Verify 1
-	gx := vrf.pairing.NewG1().PowZn(vrf.g, X)
-	c1 := vrf.pairing.NewGT().Pair(vrf.pairing.NewG1().Mul(gx, vrf.pubKey[0]), proof[0])
-	c2 := vrf.pairing.NewGT().Pair(vrf.g, vrf.g)
-	if !(c1.Equals(c2)) {
+	gx := vrf.newKeyElement().Mul(vrf.newKeyElement().PowZn(vrf.gk, X), vrf.pubKey[0])
+	c1 := vrf.pair(proof[0], gx)
+	c2 := vrf.pair(vrf.g, vrf.gk)
+	res.term("proof", proof[0])
+	res.term("gk^x . pk", gx)
+	if !res.check(CheckKey, 0, c1, c2) {
 		return false
 	}
 
 	// Verify 2
 	gt := proof[0]
-	c3 := vrf.pairing.NewGT().Pair(gt, vrf.g)
-	if !(c3.Equals(value)) {
+	c3 := vrf.pair(gt, vrf.gk)
+	if !res.check(CheckOutput, 0, c3, value) {
 		return false
 	}
 	return true
@@ -0,0 +1,99 @@
+package vrf
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDY05DeriveChild(t *testing.T) {
+	parent := newTestVRF(t, "DY05", "bn256")
+	verifier, _ := NewVRF("DY05")
+	verifier.UseParams(parent.Params())
+	if err := verifier.SetPubKey(parent.GetPubKey()); err != nil {
+		t.Fatal(err)
+	}
+
+	child, err := parent.DeriveChild("a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := verifier.DeriveChild("a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !child.GetPubKey()[0].Equals(public.GetPubKey()[0]) {
+		t.Fatal("secret and public derivation disagree")
+	}
+	if child.input == nil || !child.input.Hash {
+		t.Fatal("child does not hash its inputs")
+	}
+
+	x := big.NewInt(7)
+	value, proof := child.Eval(x)
+	if !public.Verify(x, value, proof) {
+		t.Error("child output does not verify under the derived public key")
+	}
+	if verifier.Verify(x, value, proof) {
+		t.Error("child output verifies under the parent")
+	}
+	sibling, _ := verifier.DeriveChild("a/c")
+	if sibling.Verify(x, value, proof) {
+		t.Error("child output verifies under a sibling")
+	}
+	if _, err := parent.DeriveChild("a//b"); err != ErrDerivePath {
+		t.Errorf("empty name: %v", err)
+	}
+}
+
+func TestDY05InputDomain(t *testing.T) {
+	aVRF := newTestVRF(t, "DY05", "bn256")
+	x := big.NewInt(7)
+	value, proof := aVRF.Eval(x)
+	shifted := new(big.Int).Add(x, aVRF.pairing.NewZr().SetInt32(-1).BigInt())
+	shifted.Add(shifted, big.NewInt(1)) // x + r
+
+	for _, in := range []*big.Int{shifted, big.NewInt(-7), nil} {
+		if _, _, err := aVRF.TryEval(in); err != ErrInputDomain {
+			t.Errorf("TryEval(%v): %v, want ErrInputDomain", in, err)
+		}
+		if aVRF.Verify(in, value, proof) {
+			t.Errorf("output on %v verifies for %v", x, in)
+		}
+	}
+}
+
+func TestInputDomain(t *testing.T) {
+	x := big.NewInt(7)
+	for _, typeVRF := range schemes {
+		for _, policy := range []*InputPolicy{nil, {Hash: true}} {
+			aVRF := newTestVRF(t, typeVRF, "bn256")
+			aVRF.SetInputPolicy(policy)
+			if _, _, err := aVRF.TryEval(nil); err != ErrInputDomain {
+				t.Errorf("%s, policy %v: TryEval(nil): %v", typeVRF, policy, err)
+			}
+			value, proof := aVRF.Eval(x)
+			if res := aVRF.VerifyDetailed(nil, value, proof); res.Valid || res.Kind != CheckInput {
+				t.Errorf("%s, policy %v: nil input: %v", typeVRF, policy, res.Err)
+			}
+		}
+	}
+}
+
+func TestCodeVerifyNegativeInput(t *testing.T) {
+	for _, typeVRF := range []string{"BMR10", "DOD03"} {
+		aVRF := newTestVRF(t, typeVRF, "bn256")
+		value, proof := aVRF.Eval(big.NewInt(0))
+		for _, x := range []*big.Int{big.NewInt(-1), big.NewInt(-8), nil} {
+			res := newVerifyResult(typeVRF, nil)
+			var valid bool
+			if typeVRF == "BMR10" {
+				valid = aVRF.BMR10VerifyDetailed(x, value, proof, res)
+			} else {
+				valid = aVRF.DOD03VerifyDetailed(x, value, proof, res)
+			}
+			if valid || res.Kind != CheckInput || res.Err != ErrInputDomain {
+				t.Errorf("%s: input %v: %v", typeVRF, x, res.Err)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package vrf
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPlacementGroups(t *testing.T) {
+	x := big.NewInt(3)
+	for _, tc := range []struct {
+		placement  Placement
+		key, proof bn256Kind
+	}{
+		{PubKeyInG2, bn256G2, bn256G1},
+		{PubKeyInG1, bn256G1, bn256G2},
+	} {
+		if p, ok := ParsePlacement(tc.placement.String()); !ok || p != tc.placement {
+			t.Errorf("ParsePlacement(%v) = %v, %v", tc.placement, p, ok)
+		}
+		for _, typeVRF := range schemes {
+			aVRF := newTestVRF(t, typeVRF, "bn256", WithPlacement(tc.placement))
+			value, proof := aVRF.Eval(x)
+			valueKind := bn256GT
+			if typeVRF == "DOD03" {
+				valueKind = tc.proof // v[n], the end of the chain
+			}
+			if bn(value).kind != valueKind {
+				t.Errorf("%s, keys in %v: value in the wrong group", typeVRF, tc.placement)
+			}
+			for i, el := range aVRF.GetPubKey() {
+				if bn(el).kind != tc.key {
+					t.Errorf("%s, keys in %v: pk[%d] in the proof group", typeVRF, tc.placement, i)
+				}
+			}
+			for i, el := range proof {
+				if bn(el).kind != tc.proof {
+					t.Errorf("%s, keys in %v: proof[%d] in the key group", typeVRF, tc.placement, i)
+				}
+			}
+
+			verifier, _ := NewVRF(typeVRF)
+			if err := verifier.UnMarshalParams(aVRF.MarshalParams()); err != nil {
+				t.Fatal(err)
+			}
+			if verifier.placement != tc.placement {
+				t.Errorf("%s, keys in %v: params give %v", typeVRF, tc.placement, verifier.placement)
+			}
+		}
+	}
+	if _, ok := ParsePlacement("GT"); ok {
+		t.Error("ParsePlacement(GT) accepted")
+	}
+}